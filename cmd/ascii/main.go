@@ -1,33 +1,77 @@
 package main
 
 import (
+    "context"
+    "errors"
     "fmt"
     "log"
+    "os"
+    "os/signal"
+    "syscall"
 
     "github.com/hai119/Go-ASCII-generator/internal/config"
     "github.com/hai119/Go-ASCII-generator/internal/converter"
+    "github.com/hai119/Go-ASCII-generator/internal/progress"
 )
 
+// converters 每种 -mode 对应的转换方法
+var converters = map[string]func(ctx context.Context, cfg *config.Config) error{
+    "image2text":  converter.ImageToTextContext,
+    "image2image": converter.ImageToImageColorContext,
+    "video2text":  converter.VideoToTextContext,
+    "video2video": converter.VideoToVideoColorContext,
+    "text2image":  converter.TextToImageContext,
+    "text2video":  converter.TextToVideoContext,
+    "render":      converter.RenderGridFileContext,
+}
+
 func main() {
     // 解析命令行参数
     cfg := config.ParseFlags()
 
+    // 使用 -config 指定的配置文件补充命令行中没有设置的参数
+    if cfg.ConfigPath != "" {
+        appCfg, err := config.LoadConfig(cfg.ConfigPath)
+        if err != nil {
+            log.Fatal(err)
+        }
+        cfg = config.MergeWithFlags(cfg, appCfg)
+    }
+
+    // 收到 Ctrl-C 或 SIGTERM 时取消转换，ffmpeg 子进程随之终止，未完成的输出被删除
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    // 进度输出到标准输出：终端上显示进度条，否则输出日志行或 JSON 行。
+    // 转换结果写入标准输出时，进度改为输出到标准错误。
+    progressOut := os.Stdout
+    if cfg.OutputPath == "-" {
+        progressOut = os.Stderr
+    }
+    renderer, err := progress.New(cfg.Progress, progressOut)
+    if err != nil {
+        log.Fatal(err)
+    }
+    if renderer != nil {
+        ctx = progress.WithReporter(ctx, renderer)
+    }
+
     // 根据模式选择转换方法
-    var err error
-    switch cfg.Mode {
-    case "image2text":
-        err = converter.ImageToText(cfg)
-    case "image2image":
-        err = converter.ImageToImageColor(cfg)
-    case "video2text":
-        err = converter.VideoToText(cfg)
-    case "video2video":
-        err = converter.VideoToVideoColor(cfg)
-    default:
+    if convert, ok := converters[cfg.Mode]; ok {
+        err = convert(ctx, cfg)
+    } else {
         err = fmt.Errorf("unsupported mode: %s", cfg.Mode)
     }
+    if renderer != nil {
+        renderer.Close()
+    }
 
+    if errors.Is(err, context.Canceled) {
+        stop()
+        fmt.Fprintln(os.Stderr, "interrupted")
+        os.Exit(130)
+    }
     if err != nil {
         log.Fatal(err)
     }
-} 
\ No newline at end of file
+}
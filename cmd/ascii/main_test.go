@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 测试每种有转换方法的模式都能通过 -mode 的校验，每种可以通过校验的模式都有转换方法
+func TestConvertersMatchModes(t *testing.T) {
+	valid := map[string]bool{}
+	for _, mode := range config.Modes {
+		valid[mode] = true
+		if converters[mode] == nil {
+			t.Errorf("mode %s has no converter", mode)
+		}
+	}
+	for mode := range converters {
+		if !valid[mode] {
+			t.Errorf("mode %s is dispatched but rejected by -mode validation", mode)
+		}
+	}
+}
@@ -1,10 +1,21 @@
 package alphabets
 
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// DefaultLanguage 未指定语言时使用的语言
+const DefaultLanguage = "english"
+
 // 不同语言的字符集定义
 var (
     GENERAL = map[string]string{
         "standard": "@%#*+=-:. ",
         "complex":  "$@B%8&WM#*oahkbdpqwmZO0QLCJUYXzcvunxrjft/\\|()1{}[]?-_+~<>i!lI;:,\"^`'. ",
+        // shade CP437 阴影块，适合输出为 .ans 等使用代码页 437 的格式
+        "shade": "█▓▒░ ",
     }
 
     ENGLISH = map[string]string{
@@ -22,4 +33,78 @@ var (
     KOREAN = map[string]string{
         "standard": "ㄱㄴㄷㄹㅁㅂㅅㅇㅈㅊㅋㅌㅍㅎㅏㅑㅓㅕㅗㅛㅜㅠㅡㅣ",
     }
-) 
\ No newline at end of file
+)
+
+// tables 语言到字符集表的映射
+// 拉丁字母语言额外提供 simple/complex/shade 三种通用字符集
+var tables = map[string]map[string]string{
+    "general":  withGeneral(GENERAL),
+    "english":  withGeneral(ENGLISH),
+    "chinese":  CHINESE,
+    "japanese": JAPANESE,
+    "korean":   KOREAN,
+}
+
+// defaultCharsets 各语言在未指定字符集时使用的字符集
+var defaultCharsets = map[string]string{
+    "general":  "complex",
+    "english":  "complex",
+    "chinese":  "standard",
+    "japanese": "standard",
+    "korean":   "standard",
+}
+
+// withGeneral 在语言字符集表中加入通用的 simple/complex/shade 字符集
+func withGeneral(table map[string]string) map[string]string {
+    merged := map[string]string{
+        "simple":  GENERAL["standard"],
+        "complex": GENERAL["complex"],
+        "shade":   GENERAL["shade"],
+    }
+    for name, chars := range table {
+        merged[name] = chars
+    }
+    return merged
+}
+
+// Lookup 根据语言和字符集名称查找字符集
+// 语言为空时使用 DefaultLanguage，字符集为空或为 "default" 时使用该语言的默认字符集。
+// 未知的语言或该语言不支持的字符集会返回错误。
+func Lookup(language, charset string) (string, error) {
+    if language == "" {
+        language = DefaultLanguage
+    }
+    table, ok := tables[language]
+    if !ok {
+        return "", fmt.Errorf("unsupported language: %s (available: %s)", language, strings.Join(Languages(), ", "))
+    }
+
+    if charset == "" || charset == "default" {
+        charset = defaultCharsets[language]
+    }
+    chars, ok := table[charset]
+    if !ok {
+        return "", fmt.Errorf("unsupported charset %q for language %s (available: %s)", charset, language, strings.Join(Charsets(language), ", "))
+    }
+    return chars, nil
+}
+
+// Languages 返回所有支持的语言，按字母排序
+func Languages() []string {
+    names := make([]string, 0, len(tables))
+    for name := range tables {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Charsets 返回指定语言支持的字符集名称，按字母排序
+func Charsets(language string) []string {
+    names := make([]string, 0, len(tables[language]))
+    for name := range tables[language] {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -10,16 +10,61 @@ import (
 )
 
 type Config struct {
-	InputPath    string
-	OutputPath   string
-	Mode         string
-	NumCols      int
-	Background   string
-	CharMode     string
-	Scale        float64
-	FPS          int
-	OverlayRatio float64
-	Language     string
+	InputPath      string
+	OutputPath     string
+	Mode           string
+	NumCols        int
+	Background     string
+	CharMode       string
+	Scale          float64
+	FPS            int
+	OverlayRatio   float64
+	Language       string
+	Calibrate      bool
+	RampLevels     int
+	GlyphMode      string
+	EdgeThreshold  float64
+	EdgeGlyphs     string
+	Dither         string
+	ColorSpace     string
+	Workers        int
+	Progress       string
+	FontPath       string
+	Format         string
+	JPEGQuality    int
+	PNGCompression string
+	Transparent    bool
+	ANSIColors     string
+	ANSIBackground bool
+	HTMLFragment   bool
+	HTMLFont       string
+	HTMLTheme      bool
+	SVGFont        string
+	SAUCETitle     string
+	SAUCEAuthor    string
+	SAUCEGroup     string
+	SAUCEFont      string
+	CellWidth      int
+	CellHeight     int
+	Padding        int
+	Tone           ToneConfig
+	ConfigPath     string
+
+	// setFlags 命令行中显式设置的参数，由 ParseFlags 通过 flag.Visit 记录
+	setFlags map[string]bool
+}
+
+// ToneConfig holds the tone-mapping stage applied between sampling and glyph selection
+type ToneConfig struct {
+	Gamma      float64 `yaml:"gamma" json:"gamma"`
+	Brightness float64 `yaml:"brightness" json:"brightness"`
+	Contrast   float64 `yaml:"contrast" json:"contrast"`
+	BlackPoint float64 `yaml:"black_point" json:"black_point"`
+	WhitePoint float64 `yaml:"white_point" json:"white_point"`
+	AutoLevels bool    `yaml:"auto_levels" json:"auto_levels"`
+	Equalize   string  `yaml:"equalize" json:"equalize"`
+	ClaheClip  float64 `yaml:"clahe_clip" json:"clahe_clip"`
+	Invert     string  `yaml:"invert" json:"invert"`
 }
 
 // ParseFlags parses command line flags and processes paths
@@ -27,21 +72,63 @@ func ParseFlags() *Config {
 	cfg := &Config{}
 
 	flag.StringVar(&cfg.InputPath, "input", "data/input.jpg", "Path to input file")
-	flag.StringVar(&cfg.OutputPath, "output", "data/output.txt", "Path to output file")
-	flag.StringVar(&cfg.Mode, "mode", "image2text", "Conversion mode: image2text/image2image/video2video")
+	flag.StringVar(&cfg.OutputPath, "output", "data/output.txt", "Path to output file (- writes to stdout)")
+	flag.StringVar(&cfg.Mode, "mode", "image2text", "Conversion mode: image2text/image2image/video2video/text2image (render a text, ANSI or .ans file)/text2video (render video2text output to mp4 or gif)/render (re-render a json or grid export)")
 	flag.IntVar(&cfg.NumCols, "cols", 100, "Number of columns in output")
 	flag.StringVar(&cfg.Background, "bg", "black", "Background color: black/white")
-	flag.StringVar(&cfg.CharMode, "char-mode", "complex", "Character set: simple/complex")
+	flag.StringVar(&cfg.CharMode, "char-mode", "", "Character set: simple/complex/standard/shade (shade blocks; default depends on -lang)")
 	flag.Float64Var(&cfg.Scale, "scale", 1.0, "Output scale")
-	flag.IntVar(&cfg.FPS, "fps", 0, "Frames per second (for video)")
+	flag.IntVar(&cfg.FPS, "fps", 0, "Frames per second (for video; text2video defaults to 10)")
 	flag.Float64Var(&cfg.OverlayRatio, "overlay", 0.2, "Overlay ratio for video")
-	flag.StringVar(&cfg.Language, "lang", "english", "Language for characters")
+	flag.StringVar(&cfg.Language, "lang", "english", "Language for characters: general/english/chinese/japanese/korean")
+	flag.BoolVar(&cfg.Calibrate, "calibrate", false, "Sort the character ramp by measured glyph density of the chosen font")
+	flag.IntVar(&cfg.RampLevels, "ramp-levels", 0, "Resample the calibrated ramp to this many evenly spaced densities (0 keeps all characters)")
+	flag.StringVar(&cfg.GlyphMode, "glyph-mode", "brightness", "Glyph selection: brightness/structure/edge/braille/halfblock/quadrant/sextant")
+	flag.Float64Var(&cfg.EdgeThreshold, "edge-threshold", 0.25, "Normalized Sobel magnitude above which a pixel counts as an edge (edge glyph mode)")
+	flag.StringVar(&cfg.EdgeGlyphs, "edge-glyphs", "ascii", "Orientation glyphs for edge mode: ascii/box")
+	flag.StringVar(&cfg.Dither, "dither", "none", "Dithering before glyph lookup: none/floyd-steinberg/atkinson/jjn/bayer")
+	flag.StringVar(&cfg.ColorSpace, "color-space", "srgb", "Color space for brightness and color averaging: srgb/linear/oklab")
+	flag.IntVar(&cfg.Workers, "workers", 0, "Number of parallel workers (0 uses GOMAXPROCS)")
+	flag.StringVar(&cfg.Format, "format", "", "Output format for image modes: text/ansi/ans/xbin/html/svg/json/grid/jpeg/png/gif/bmp/tiff/webp, mp4/gif for text2video (default: detected from the -output extension)")
+	flag.IntVar(&cfg.JPEGQuality, "jpeg-quality", 0, "JPEG quality 1-100 (0 uses the default of 75)")
+	flag.StringVar(&cfg.PNGCompression, "png-compression", "default", "PNG and TIFF compression: default/none/fast/best")
+	flag.BoolVar(&cfg.Transparent, "transparent", false, "Transparent background for image output (png/tiff/webp only)")
+	flag.StringVar(&cfg.ANSIColors, "ansi-colors", "auto", "Colors for ansi output: auto (detected from COLORTERM/TERM when writing to stdout, truecolor otherwise)/truecolor/256/16/none")
+	flag.BoolVar(&cfg.ANSIBackground, "ansi-background", false, "Paint the -bg color behind every character of ansi output")
+	flag.BoolVar(&cfg.HTMLFragment, "html-fragment", false, "Write only the <pre> element of html output instead of a full page")
+	flag.StringVar(&cfg.HTMLFont, "html-font", "", "CSS font-family stack for html output (default: common monospace fonts)")
+	flag.BoolVar(&cfg.HTMLTheme, "html-theme", false, "Take html colors and font from the CSS variables --ascii-bg, --ascii-fg and --ascii-font when set")
+	flag.StringVar(&cfg.SVGFont, "svg-font", "reference", "Font handling for svg output: reference (by family name)/embed (font file inlined)/outline (glyphs converted to paths)")
+	flag.StringVar(&cfg.SAUCETitle, "sauce-title", "", "Title in the SAUCE record of ans/xbin output (default: input file name)")
+	flag.StringVar(&cfg.SAUCEAuthor, "sauce-author", "", "Author in the SAUCE record of ans/xbin output")
+	flag.StringVar(&cfg.SAUCEGroup, "sauce-group", "", "Group in the SAUCE record of ans/xbin output")
+	flag.StringVar(&cfg.SAUCEFont, "sauce-font", "IBM VGA", "Font name in the SAUCE record of ans output, used by viewers to pick a bitmap font")
+	flag.IntVar(&cfg.CellWidth, "cell-width", 0, "Cell width in pixels for text2image (0 uses the font's advance width)")
+	flag.IntVar(&cfg.CellHeight, "cell-height", 0, "Cell height in pixels for text2image (0 uses the font's line height)")
+	flag.IntVar(&cfg.Padding, "padding", 0, "Pixels of background around raster image output")
+	flag.StringVar(&cfg.FontPath, "font", "", "TrueType font file for image output (default depends on -lang)")
+	flag.StringVar(&cfg.Progress, "progress", "auto", "Progress output on stdout: auto (bar on a terminal, log lines otherwise)/bar/log/json/none")
+	flag.Float64Var(&cfg.Tone.Gamma, "gamma", 1.0, "Gamma applied to sampled brightness (>1 brightens)")
+	flag.Float64Var(&cfg.Tone.Brightness, "brightness", 0, "Brightness offset added to sampled brightness (-1 to 1)")
+	flag.Float64Var(&cfg.Tone.Contrast, "contrast", 1.0, "Contrast multiplier around mid-gray")
+	flag.Float64Var(&cfg.Tone.BlackPoint, "black-point", 0, "Brightness mapped to black (0-1)")
+	flag.Float64Var(&cfg.Tone.WhitePoint, "white-point", 1.0, "Brightness mapped to white (0-1)")
+	flag.BoolVar(&cfg.Tone.AutoLevels, "auto-levels", false, "Stretch the brightness range of each image to the full ramp")
+	flag.StringVar(&cfg.Tone.Equalize, "equalize", "none", "Histogram equalization: none/histogram/clahe")
+	flag.Float64Var(&cfg.Tone.ClaheClip, "clahe-clip", 2.0, "CLAHE clip limit as a multiple of the mean histogram bin")
+	flag.StringVar(&cfg.Tone.Invert, "invert", "auto", "Invert brightness: auto (on light backgrounds)/true/false")
+
+	flag.StringVar(&cfg.ConfigPath, "config", "", "YAML config file whose defaults apply to flags not given on the command line")
 
 	flag.Parse()
+	cfg.setFlags = map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		cfg.setFlags[f.Name] = true
+	})
 
 	// Print initial debug message for verbosity
 	if isVerboseMode() {
-		fmt.Println("Flags successfully parsed, starting to process paths...")
+		fmt.Fprintln(os.Stderr, "Flags successfully parsed, starting to process paths...")
 	}
 
 	// 处理路径
@@ -49,13 +136,13 @@ func ParseFlags() *Config {
 
 	// After paths are processed, check if mode is valid
 	if !isValidMode(cfg.Mode) {
-		fmt.Println("Invalid mode specified. Using default mode: image2text")
+		fmt.Fprintln(os.Stderr, "Invalid mode specified. Using default mode: image2text")
 		cfg.Mode = "image2text"
 	}
 
 	// Optionally print the final configuration
 	if isVerboseMode() {
-		fmt.Println("Configuration processed successfully. Final values:")
+		fmt.Fprintln(os.Stderr, "Configuration processed successfully. Final values:")
 		printConfig(cfg)
 	}
 
@@ -75,7 +162,10 @@ func (cfg *Config) ProcessPaths() {
 		cfg.InputPath = filepath.Join(workDir, cfg.InputPath)
 	}
 
-	// 处理输出路径
+	// 处理输出路径，"-" 表示标准输出
+	if cfg.OutputPath == "-" {
+		return
+	}
 	if !filepath.IsAbs(cfg.OutputPath) {
 		cfg.OutputPath = filepath.Join(workDir, cfg.OutputPath)
 	}
@@ -84,7 +174,7 @@ func (cfg *Config) ProcessPaths() {
 	outputDir := filepath.Dir(cfg.OutputPath)
 	err = os.MkdirAll(outputDir, 0755)
 	if err != nil {
-		fmt.Printf("Warning: failed to create output directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to create output directory: %v\n", err)
 	}
 }
 
@@ -93,10 +183,12 @@ func isVerboseMode() bool {
 	return strings.ToLower(os.Getenv("VERBOSE_MODE")) == "true"
 }
 
+// Modes lists the conversion modes accepted by -mode
+var Modes = []string{"image2text", "image2image", "video2text", "video2video", "text2image", "text2video", "render"}
+
 // isValidMode checks if the specified mode is valid
 func isValidMode(mode string) bool {
-	validModes := []string{"image2text", "image2image", "video2video"}
-	for _, valid := range validModes {
+	for _, valid := range Modes {
 		if mode == valid {
 			return true
 		}
@@ -104,18 +196,42 @@ func isValidMode(mode string) bool {
 	return false
 }
 
-// printConfig prints the configuration in a readable format
+// printConfig prints the configuration in a readable format to stderr
 func printConfig(cfg *Config) {
-	fmt.Printf("Input Path: %s\n", cfg.InputPath)
-	fmt.Printf("Output Path: %s\n", cfg.OutputPath)
-	fmt.Printf("Mode: %s\n", cfg.Mode)
-	fmt.Printf("Columns: %d\n", cfg.NumCols)
-	fmt.Printf("Background: %s\n", cfg.Background)
-	fmt.Printf("Character Mode: %s\n", cfg.CharMode)
-	fmt.Printf("Scale: %f\n", cfg.Scale)
-	fmt.Printf("FPS: %d\n", cfg.FPS)
-	fmt.Printf("Overlay Ratio: %f\n", cfg.OverlayRatio)
-	fmt.Printf("Language: %s\n", cfg.Language)
+	fmt.Fprintf(os.Stderr, "Input Path: %s\n", cfg.InputPath)
+	fmt.Fprintf(os.Stderr, "Output Path: %s\n", cfg.OutputPath)
+	fmt.Fprintf(os.Stderr, "Mode: %s\n", cfg.Mode)
+	fmt.Fprintf(os.Stderr, "Columns: %d\n", cfg.NumCols)
+	fmt.Fprintf(os.Stderr, "Background: %s\n", cfg.Background)
+	fmt.Fprintf(os.Stderr, "Character Mode: %s\n", cfg.CharMode)
+	fmt.Fprintf(os.Stderr, "Scale: %f\n", cfg.Scale)
+	fmt.Fprintf(os.Stderr, "FPS: %d\n", cfg.FPS)
+	fmt.Fprintf(os.Stderr, "Overlay Ratio: %f\n", cfg.OverlayRatio)
+	fmt.Fprintf(os.Stderr, "Language: %s\n", cfg.Language)
+	fmt.Fprintf(os.Stderr, "Calibrate: %t\n", cfg.Calibrate)
+	fmt.Fprintf(os.Stderr, "Ramp Levels: %d\n", cfg.RampLevels)
+	fmt.Fprintf(os.Stderr, "Glyph Mode: %s\n", cfg.GlyphMode)
+	fmt.Fprintf(os.Stderr, "Edge Threshold: %f\n", cfg.EdgeThreshold)
+	fmt.Fprintf(os.Stderr, "Edge Glyphs: %s\n", cfg.EdgeGlyphs)
+	fmt.Fprintf(os.Stderr, "Dither: %s\n", cfg.Dither)
+	fmt.Fprintf(os.Stderr, "Color Space: %s\n", cfg.ColorSpace)
+	fmt.Fprintf(os.Stderr, "Workers: %d\n", cfg.Workers)
+	fmt.Fprintf(os.Stderr, "Progress: %s\n", cfg.Progress)
+	fmt.Fprintf(os.Stderr, "Font: %s\n", cfg.FontPath)
+	fmt.Fprintf(os.Stderr, "Format: %s\n", cfg.Format)
+	fmt.Fprintf(os.Stderr, "JPEG Quality: %d\n", cfg.JPEGQuality)
+	fmt.Fprintf(os.Stderr, "PNG Compression: %s\n", cfg.PNGCompression)
+	fmt.Fprintf(os.Stderr, "Transparent: %t\n", cfg.Transparent)
+	fmt.Fprintf(os.Stderr, "ANSI Colors: %s\n", cfg.ANSIColors)
+	fmt.Fprintf(os.Stderr, "ANSI Background: %t\n", cfg.ANSIBackground)
+	fmt.Fprintf(os.Stderr, "HTML Fragment: %t\n", cfg.HTMLFragment)
+	fmt.Fprintf(os.Stderr, "HTML Font: %s\n", cfg.HTMLFont)
+	fmt.Fprintf(os.Stderr, "HTML Theme: %t\n", cfg.HTMLTheme)
+	fmt.Fprintf(os.Stderr, "SVG Font: %s\n", cfg.SVGFont)
+	fmt.Fprintf(os.Stderr, "Cell Size: %dx%d\n", cfg.CellWidth, cfg.CellHeight)
+	fmt.Fprintf(os.Stderr, "Padding: %d\n", cfg.Padding)
+	fmt.Fprintf(os.Stderr, "SAUCE: %q by %q (%q), font %q\n", cfg.SAUCETitle, cfg.SAUCEAuthor, cfg.SAUCEGroup, cfg.SAUCEFont)
+	fmt.Fprintf(os.Stderr, "Tone: %+v\n", cfg.Tone)
 }
 
 // RetryOperation attempts an operation multiple times in case of failure
@@ -126,7 +242,7 @@ func RetryOperation(operation func() error, retries int, delay time.Duration) er
 		if err == nil {
 			return nil
 		}
-		fmt.Printf("Attempt %d failed, retrying in %s...\n", i+1, delay)
+		fmt.Fprintf(os.Stderr, "Attempt %d failed, retrying in %s...\n", i+1, delay)
 		time.Sleep(delay)
 	}
 	return fmt.Errorf("operation failed after %d retries: %w", retries, err)
@@ -135,6 +251,6 @@ func RetryOperation(operation func() error, retries int, delay time.Duration) er
 // DebugMode logs the configuration if debug mode is on
 func DebugMode() {
 	if isVerboseMode() {
-		fmt.Println("Debug Mode Active")
+		fmt.Fprintln(os.Stderr, "Debug Mode Active")
 	}
 }
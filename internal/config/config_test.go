@@ -2,12 +2,14 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseFlags(t *testing.T) {
@@ -96,6 +98,7 @@ func TestIsValidMode(t *testing.T) {
 	// 验证有效模式
 	assert.True(t, isValidMode("image2text"), "Mode 'image2text' should be valid")
 	assert.True(t, isValidMode("image2image"), "Mode 'image2image' should be valid")
+	assert.True(t, isValidMode("video2text"), "Mode 'video2text' should be valid")
 	assert.True(t, isValidMode("video2video"), "Mode 'video2video' should be valid")
 
 	// 验证无效模式
@@ -117,9 +120,33 @@ func TestPrintConfig(t *testing.T) {
 		Language:     "english",
 	}
 
-	// 由于是打印输出，我们这里不直接断言，而是通过捕获输出验证
-	// 使用 `os.Stdout` 来捕获输出
-	// 使用自定义的函数来验证输出
-	printConfig(cfg)
-	// 可以扩展捕获输出进行断言
+	// 配置输出到 stderr，stdout 留给 -output - 的转换结果
+	stdout, stderr := captureOutput(t, func() { printConfig(cfg) })
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "Input Path: data/input.jpg")
+	assert.Contains(t, stderr, "Mode: image2text")
+}
+
+// captureOutput runs fn and returns what it wrote to stdout and stderr
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	read := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		orig := *target
+		*target = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			*target = orig
+			w.Close()
+			return <-done
+		}
+	}
+	stdout, stderr := read(&os.Stdout), read(&os.Stderr)
+	fn()
+	return stdout(), stderr()
 }
@@ -15,13 +15,14 @@ type AppConfig struct {
 	} `yaml:"app"`
 
 	Defaults struct {
-		Mode         string  `yaml:"mode"`
-		Background   string  `yaml:"background"`
-		NumCols      int     `yaml:"num_cols"`
-		Scale        float64 `yaml:"scale"`
-		FPS          int     `yaml:"fps"`
-		OverlayRatio float64 `yaml:"overlay_ratio"`
-		Language     string  `yaml:"language"`
+		Mode         string     `yaml:"mode"`
+		Background   string     `yaml:"background"`
+		NumCols      int        `yaml:"num_cols"`
+		Scale        float64    `yaml:"scale"`
+		FPS          int        `yaml:"fps"`
+		OverlayRatio float64    `yaml:"overlay_ratio"`
+		Language     string     `yaml:"language"`
+		Tone         ToneConfig `yaml:"tone"`
 	} `yaml:"defaults"`
 
 	Fonts struct {
@@ -33,6 +34,9 @@ type AppConfig struct {
 	Output struct {
 		SupportedFormats []string `yaml:"supported_formats"`
 	} `yaml:"output"`
+
+	// defaultsSet 配置文件 defaults 部分中出现的键，色调设置记为 "tone.gamma" 的形式，由 LoadConfig 记录
+	defaultsSet map[string]bool
 }
 
 // LoadConfig 加载配置文件
@@ -57,42 +61,117 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// 记录 defaults 中出现的键，值为零的设置同样覆盖命令行参数的默认值
+	var present struct {
+		Defaults map[string]interface{} `yaml:"defaults"`
+	}
+	var presentTone struct {
+		Defaults struct {
+			Tone map[string]interface{} `yaml:"tone"`
+		} `yaml:"defaults"`
+	}
+	if err := yaml.Unmarshal(data, &present); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &presentTone); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	config.defaultsSet = map[string]bool{}
+	for key, value := range present.Defaults {
+		config.defaultsSet[key] = value != nil
+	}
+	for key, value := range presentTone.Defaults.Tone {
+		config.defaultsSet["tone."+key] = value != nil
+	}
+
 	return &config, nil
 }
 
-// MergeWithFlags 将命令行参数与配置文件合并
+// MergeWithFlags 将命令行参数与配置文件合并，配置文件中设置的默认值只用于命令行中没有显式设置的参数
 func MergeWithFlags(cfg *Config, appCfg *AppConfig) *Config {
-	if cfg.Mode == "" {
-		cfg.Mode = appCfg.Defaults.Mode
+	d := appCfg.Defaults
+	if !cfg.flagSet("mode", cfg.Mode == "") && appCfg.defaultSet("mode", d.Mode != "") {
+		cfg.Mode = d.Mode
 	}
-	if cfg.Background == "" {
-		cfg.Background = appCfg.Defaults.Background
+	if !cfg.flagSet("bg", cfg.Background == "") && appCfg.defaultSet("background", d.Background != "") {
+		cfg.Background = d.Background
 	}
-	if cfg.NumCols == 0 {
-		cfg.NumCols = appCfg.Defaults.NumCols
+	if !cfg.flagSet("cols", cfg.NumCols == 0) && appCfg.defaultSet("num_cols", d.NumCols != 0) {
+		cfg.NumCols = d.NumCols
 	}
-	if cfg.Scale == 0 {
-		cfg.Scale = appCfg.Defaults.Scale
+	if !cfg.flagSet("scale", cfg.Scale == 0) && appCfg.defaultSet("scale", d.Scale != 0) {
+		cfg.Scale = d.Scale
 	}
-	if cfg.FPS == 0 {
-		cfg.FPS = appCfg.Defaults.FPS
+	if !cfg.flagSet("fps", cfg.FPS == 0) && appCfg.defaultSet("fps", d.FPS != 0) {
+		cfg.FPS = d.FPS
 	}
-	if cfg.OverlayRatio == 0 {
-		cfg.OverlayRatio = appCfg.Defaults.OverlayRatio
+	if !cfg.flagSet("overlay", cfg.OverlayRatio == 0) && appCfg.defaultSet("overlay_ratio", d.OverlayRatio != 0) {
+		cfg.OverlayRatio = d.OverlayRatio
 	}
-	if cfg.Language == "" {
-		cfg.Language = appCfg.Defaults.Language
+	if !cfg.flagSet("lang", cfg.Language == "") && appCfg.defaultSet("language", d.Language != "") {
+		cfg.Language = d.Language
 	}
+	mergeTone(cfg, appCfg)
 
 	// 如果启用调试模式，输出最终合并的配置
 	if isVerboseMode() {
-		fmt.Println("Configuration after merging with flags:")
+		fmt.Fprintln(os.Stderr, "Configuration after merging with flags:")
 		printConfig(cfg)
 	}
 
 	return cfg
 }
 
+// mergeTone 用配置文件中的色调设置替换命令行未设置的字段
+func mergeTone(cfg *Config, appCfg *AppConfig) {
+	tone, defaults := &cfg.Tone, appCfg.Defaults.Tone
+	if !cfg.flagSet("gamma", tone.Gamma == 0) && appCfg.defaultSet("tone.gamma", defaults.Gamma != 0) {
+		tone.Gamma = defaults.Gamma
+	}
+	if !cfg.flagSet("brightness", tone.Brightness == 0) && appCfg.defaultSet("tone.brightness", defaults.Brightness != 0) {
+		tone.Brightness = defaults.Brightness
+	}
+	if !cfg.flagSet("contrast", tone.Contrast == 0) && appCfg.defaultSet("tone.contrast", defaults.Contrast != 0) {
+		tone.Contrast = defaults.Contrast
+	}
+	if !cfg.flagSet("black-point", tone.BlackPoint == 0) && appCfg.defaultSet("tone.black_point", defaults.BlackPoint != 0) {
+		tone.BlackPoint = defaults.BlackPoint
+	}
+	if !cfg.flagSet("white-point", tone.WhitePoint == 0) && appCfg.defaultSet("tone.white_point", defaults.WhitePoint != 0) {
+		tone.WhitePoint = defaults.WhitePoint
+	}
+	if !cfg.flagSet("auto-levels", !tone.AutoLevels) && appCfg.defaultSet("tone.auto_levels", defaults.AutoLevels) {
+		tone.AutoLevels = defaults.AutoLevels
+	}
+	if !cfg.flagSet("equalize", tone.Equalize == "") && appCfg.defaultSet("tone.equalize", defaults.Equalize != "") {
+		tone.Equalize = defaults.Equalize
+	}
+	if !cfg.flagSet("clahe-clip", tone.ClaheClip == 0) && appCfg.defaultSet("tone.clahe_clip", defaults.ClaheClip != 0) {
+		tone.ClaheClip = defaults.ClaheClip
+	}
+	if !cfg.flagSet("invert", tone.Invert == "") && appCfg.defaultSet("tone.invert", defaults.Invert != "") {
+		tone.Invert = defaults.Invert
+	}
+}
+
+// flagSet 返回参数 name 是否由用户设置。
+// ParseFlags 创建的配置按命令行中是否出现该参数判断，其他配置按字段是否为零值 zero 判断。
+func (cfg *Config) flagSet(name string, zero bool) bool {
+	if cfg.setFlags == nil {
+		return !zero
+	}
+	return cfg.setFlags[name]
+}
+
+// defaultSet 返回配置文件的 defaults 中是否设置了 key。
+// LoadConfig 加载的配置按文件中是否出现该键判断，其他配置按值是否非零 nonZero 判断。
+func (appCfg *AppConfig) defaultSet(key string, nonZero bool) bool {
+	if appCfg.defaultsSet == nil {
+		return nonZero
+	}
+	return appCfg.defaultsSet[key]
+}
+
 // fileExists 检查文件是否存在
 func fileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
@@ -114,13 +114,14 @@ func TestMergeWithFlags(t *testing.T) {
 			Version: "1.0",
 		},
 		Defaults: struct {
-			Mode         string  `yaml:"mode"`
-			Background   string  `yaml:"background"`
-			NumCols      int     `yaml:"num_cols"`
-			Scale        float64 `yaml:"scale"`
-			FPS          int     `yaml:"fps"`
-			OverlayRatio float64 `yaml:"overlay_ratio"`
-			Language     string  `yaml:"language"`
+			Mode         string     `yaml:"mode"`
+			Background   string     `yaml:"background"`
+			NumCols      int        `yaml:"num_cols"`
+			Scale        float64    `yaml:"scale"`
+			FPS          int        `yaml:"fps"`
+			OverlayRatio float64    `yaml:"overlay_ratio"`
+			Language     string     `yaml:"language"`
+			Tone         ToneConfig `yaml:"tone"`
 		}{
 			Mode:         "dark",
 			Background:   "black",
@@ -129,6 +130,12 @@ func TestMergeWithFlags(t *testing.T) {
 			FPS:          60,
 			OverlayRatio: 0.8,
 			Language:     "en",
+			Tone: ToneConfig{
+				Gamma:    2.2,
+				Contrast: 1.2,
+				Equalize: "clahe",
+				Invert:   "false",
+			},
 		},
 		Fonts: struct {
 			BasePath    string            `yaml:"base_path"`
@@ -162,6 +169,9 @@ func TestMergeWithFlags(t *testing.T) {
 		assert.Equal(t, "dark", mergedConfig.Mode)       // Should fallback to default
 		assert.Equal(t, "blue", mergedConfig.Background) // Should be overridden by the flag
 		assert.Equal(t, 10, mergedConfig.NumCols)        // Should fallback to default
+		assert.Equal(t, 2.2, mergedConfig.Tone.Gamma)    // Tone settings fall back to defaults
+		assert.Equal(t, "clahe", mergedConfig.Tone.Equalize)
+		assert.Equal(t, "false", mergedConfig.Tone.Invert)
 	})
 
 	// Test merging with verbose mode enabled
@@ -203,4 +213,92 @@ func TestMergeWithFlags(t *testing.T) {
 		assert.Equal(t, 1.0, mergedConfig.OverlayRatio)
 		assert.Equal(t, "fr", mergedConfig.Language)
 	})
+
+	t.Run("Flag defaults replaced by config", func(t *testing.T) {
+		yamlFile, err := os.CreateTemp("", "tone_config_*.yaml")
+		require.NoError(t, err)
+		defer os.Remove(yamlFile.Name())
+		_, err = yamlFile.WriteString(`
+app:
+  name: MyApp
+  version: 1.0
+defaults:
+  mode: image2image
+  tone:
+    gamma: 2.2
+    contrast: 1.5
+fonts:
+  base_path: /path/to/fonts
+`)
+		require.NoError(t, err)
+		yamlFile.Close()
+		appCfg, err := LoadConfig(yamlFile.Name())
+		require.NoError(t, err)
+
+		// Flag defaults as set by ParseFlags, with only -mode and -contrast given on the command line
+		cfg := &Config{
+			Mode:     "image2text",
+			NumCols:  100,
+			Tone:     ToneConfig{Gamma: 1.0, Contrast: 1.2, WhitePoint: 1.0, ClaheClip: 2.0, Equalize: "none", Invert: "auto"},
+			setFlags: map[string]bool{"mode": true, "contrast": true},
+		}
+		mergedConfig := MergeWithFlags(cfg, appCfg)
+
+		assert.Equal(t, 2.2, mergedConfig.Tone.Gamma)     // Unset flag takes the YAML value
+		assert.Equal(t, 1.2, mergedConfig.Tone.Contrast)  // Explicit flag wins over YAML
+		assert.Equal(t, "image2text", mergedConfig.Mode)  // Explicit flag wins over YAML
+		assert.Equal(t, 100, mergedConfig.NumCols)        // Missing YAML value keeps the flag default
+		assert.Equal(t, "auto", mergedConfig.Tone.Invert) // Missing YAML value keeps the flag default
+	})
+
+	t.Run("Verbose output goes to stderr", func(t *testing.T) {
+		os.Setenv("VERBOSE_MODE", "true")
+		defer os.Setenv("VERBOSE_MODE", "")
+
+		cfg := &Config{Mode: "image2text"}
+		stdout, stderr := captureOutput(t, func() { MergeWithFlags(cfg, &AppConfig{}) })
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "Configuration after merging with flags:")
+		assert.Contains(t, stderr, "Mode: image2text")
+	})
+
+	t.Run("Zero values in config", func(t *testing.T) {
+		yamlFile, err := os.CreateTemp("", "zero_config_*.yaml")
+		require.NoError(t, err)
+		defer os.Remove(yamlFile.Name())
+		_, err = yamlFile.WriteString(`
+app:
+  name: MyApp
+  version: 1.0
+defaults:
+  mode: image2text
+  fps: 0
+  tone:
+    brightness: 0
+    black_point: 0
+    auto_levels: false
+fonts:
+  base_path: /path/to/fonts
+`)
+		require.NoError(t, err)
+		yamlFile.Close()
+		appCfg, err := LoadConfig(yamlFile.Name())
+		require.NoError(t, err)
+
+		// Non-zero values not given on the command line are replaced by the zero values from YAML
+		cfg := &Config{
+			Mode:     "image2image",
+			FPS:      24,
+			Tone:     ToneConfig{Gamma: 1.5, Brightness: 0.3, BlackPoint: 0.1, AutoLevels: true},
+			setFlags: map[string]bool{},
+		}
+		mergedConfig := MergeWithFlags(cfg, appCfg)
+
+		assert.Equal(t, "image2text", mergedConfig.Mode)
+		assert.Equal(t, 0, mergedConfig.FPS)
+		assert.Equal(t, 0.0, mergedConfig.Tone.Brightness)
+		assert.Equal(t, 0.0, mergedConfig.Tone.BlackPoint)
+		assert.False(t, mergedConfig.Tone.AutoLevels)
+		assert.Equal(t, 1.5, mergedConfig.Tone.Gamma) // Key missing from YAML keeps the flag value
+	})
 }
@@ -0,0 +1,409 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSAUCEFont SAUCE 记录中默认的字体名称，ANSI 艺术查看器按该名称选择点阵字体
+const DefaultSAUCEFont = "IBM VGA"
+
+// SAUCE ANSI 艺术档案使用的元数据，追加在文件末尾
+type SAUCE struct {
+	Title  string
+	Author string
+	Group  string
+	// Font 查看器使用的字体名称，为空时使用 DefaultSAUCEFont
+	Font string
+	// Date 创建日期，为零值时使用当前日期
+	Date time.Time
+}
+
+// SAUCE 记录的数据类型
+const (
+	sauceCharacter = 1
+	sauceXBin      = 6
+	// sauceANSi Character 类型中的 ANSi 文件类型
+	sauceANSi = 1
+	// sauceICE TFlags 中的 iCE 颜色标志：闪烁位表示高亮背景色
+	sauceICE = 1
+)
+
+// sauceRecord 描述一个 SAUCE 记录中与文件格式有关的字段
+type sauceRecord struct {
+	dataType, fileType byte
+	width, height      int
+	flags              byte
+	font               string
+}
+
+// writeSAUCE 在 b 的内容之后追加 EOF 字符和 128 字节的 SAUCE 记录，文件大小为追加前 b 的长度
+func (s SAUCE) writeSAUCE(b *bytes.Buffer, rec sauceRecord) {
+	size := b.Len()
+	date := s.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	b.WriteByte(0x1A)
+	b.WriteString("SAUCE00")
+	b.Write(sauceField(encodeCP437(s.Title), 35, ' '))
+	b.Write(sauceField(encodeCP437(s.Author), 20, ' '))
+	b.Write(sauceField(encodeCP437(s.Group), 20, ' '))
+	b.WriteString(date.Format("20060102"))
+	binary.Write(b, binary.LittleEndian, uint32(size))
+	b.WriteByte(rec.dataType)
+	b.WriteByte(rec.fileType)
+	binary.Write(b, binary.LittleEndian, uint16(rec.width))
+	binary.Write(b, binary.LittleEndian, uint16(rec.height))
+	// TInfo3、TInfo4 和注释行数未使用
+	b.Write(make([]byte, 5))
+	b.WriteByte(rec.flags)
+	b.Write(sauceField(encodeCP437(rec.font), 22, 0))
+}
+
+// sauceField 将 s 截断或以 pad 填充到 n 字节
+func sauceField(s []byte, n int, pad byte) []byte {
+	field := bytes.Repeat([]byte{pad}, n)
+	copy(field, s)
+	return field
+}
+
+// vgaPalette VGA 文本模式的 16 色调色板，按 ANSI 颜色顺序排列，8-15 为高亮色
+var vgaPalette = newANSIPalette(0, []color.RGBA{
+	{0, 0, 0, 255}, {170, 0, 0, 255}, {0, 170, 0, 255}, {170, 85, 0, 255},
+	{0, 0, 170, 255}, {170, 0, 170, 255}, {0, 170, 170, 255}, {170, 170, 170, 255},
+	{85, 85, 85, 255}, {255, 85, 85, 255}, {85, 255, 85, 255}, {255, 255, 85, 255},
+	{85, 85, 255, 255}, {255, 85, 255, 255}, {85, 255, 255, 255}, {255, 255, 255, 255},
+})
+
+// ansTerminalWidth .ans 文件面向的标准终端宽度
+const ansTerminalWidth = 80
+
+// ANSArtRenderer 将字符网格输出为 BBS 风格的 .ans 文件：CP437 字符、16 色 ANSI 转义序列和 SAUCE 记录。
+// 使用 iCE 颜色，闪烁属性表示高亮背景色，因此前景色和背景色都可以使用全部 16 种颜色。
+type ANSArtRenderer struct {
+	SAUCE SAUCE
+	// Charset 生成网格的字符梯度，CP437 中没有的字符按其在梯度中的位置替换为阴影字符
+	Charset []rune
+	// Background 没有背景色的单元格的背景，为 nil 或透明时为黑色
+	Background color.Color
+	// Pool 用于并行生成文本，为 nil 时顺序执行
+	Pool *WorkerPool
+}
+
+// Render 实现 Renderer 接口
+// 每行结束时重置颜色，各行互不依赖，因此按行分块并行生成后按顺序拼接。
+// 行尾与默认黑色背景相同的空格被省略，每行以 CR LF 换行；
+// 只有 80 列的整行例外，终端在第 80 列之后自动换行，与常见的 ANSI 编辑器一致。
+func (r *ANSArtRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	mapper := newCP437Mapper(r.Charset)
+	page := pageColor(r.Background)
+
+	chunks, err := r.Pool.MapContext(ctx, grid.Rows, r.Pool.rowChunk(grid.Rows), func(start, end int) interface{} {
+		var chunk bytes.Buffer
+		nearest := map[color.RGBA]int{}
+		match := func(c color.RGBA) int {
+			i, ok := nearest[c]
+			if !ok {
+				i = vgaPalette.nearest(c)
+				nearest[c] = i
+			}
+			return i
+		}
+		for i := start; i < end; i++ {
+			row := grid.Row(i)
+			chars := make([]byte, len(row))
+			fgs := make([]int, len(row))
+			bgs := make([]int, len(row))
+			n := 0
+			for j, c := range row {
+				bg := c.BG
+				if bg.A == 0 {
+					bg = page
+				}
+				chars[j], fgs[j], bgs[j] = mapper.byteOf(c), match(c.FG), match(bg)
+				if chars[j] != ' ' || bgs[j] != 0 {
+					n = j + 1
+				}
+			}
+
+			state := ansState{fg: 7}
+			for j := 0; j < n; j++ {
+				fg := fgs[j]
+				if chars[j] == ' ' {
+					fg = state.fg
+				}
+				state.set(&chunk, fg, bgs[j])
+				chunk.WriteByte(chars[j])
+			}
+			if state != (ansState{fg: 7}) {
+				chunk.WriteString("\x1b[0m")
+			}
+			if n < ansTerminalWidth || len(row) != ansTerminalWidth {
+				chunk.WriteString("\r\n")
+			}
+		}
+		return chunk.Bytes()
+	})
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\x1b[0m")
+	for _, chunk := range chunks {
+		b.Write(chunk.([]byte))
+	}
+	r.SAUCE.writeSAUCE(&b, sauceRecord{
+		dataType: sauceCharacter,
+		fileType: sauceANSi,
+		width:    grid.Cols,
+		height:   grid.Rows,
+		flags:    sauceICE,
+		font:     r.fontName(),
+	})
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// fontName 返回 SAUCE 记录中的字体名称
+func (r *ANSArtRenderer) fontName() string {
+	if r.SAUCE.Font == "" {
+		return DefaultSAUCEFont
+	}
+	return r.SAUCE.Font
+}
+
+// ansState 当前生效的 16 色前景色和背景色索引，8-15 分别通过粗体和闪烁属性表示
+type ansState struct {
+	fg, bg int
+}
+
+// set 输出切换到 fg、bg 所需的最短 SGR 序列。
+// 粗体和闪烁属性只能通过整体重置关闭，此时重新设置所有属性。
+func (s *ansState) set(b *bytes.Buffer, fg, bg int) {
+	if fg == s.fg && bg == s.bg {
+		return
+	}
+	var params []string
+	if (s.fg >= 8 && fg < 8) || (s.bg >= 8 && bg < 8) {
+		params = append(params, "0")
+		*s = ansState{fg: 7}
+	}
+	if fg >= 8 && s.fg < 8 {
+		params = append(params, "1")
+	}
+	if bg >= 8 && s.bg < 8 {
+		params = append(params, "5")
+	}
+	if fg%8 != s.fg%8 {
+		params = append(params, strconv.Itoa(30+fg%8))
+	}
+	if bg%8 != s.bg%8 {
+		params = append(params, strconv.Itoa(40+bg%8))
+	}
+	*s = ansState{fg: fg, bg: bg}
+	if len(params) > 0 {
+		fmt.Fprintf(b, "\x1b[%sm", strings.Join(params, ";"))
+	}
+}
+
+// XBinRenderer 将字符网格输出为 XBin 文件：CP437 字符和属性字节，以及按图像颜色选出的 16 色调色板。
+// 标准 16 色不足以表现图像时使用，每个文件可以使用不同的 16 种颜色。
+type XBinRenderer struct {
+	SAUCE SAUCE
+	// Charset 生成网格的字符梯度，CP437 中没有的字符按其在梯度中的位置替换为阴影字符
+	Charset []rune
+	// Background 没有背景色的单元格的背景，为 nil 或透明时为黑色
+	Background color.Color
+}
+
+// xbinFontHeight 使用默认 VGA 字体时的字符高度
+const xbinFontHeight = 16
+
+// XBin 文件头中的标志位
+const (
+	xbinFlagPalette  = 1 << 0
+	xbinFlagNonBlink = 1 << 3
+)
+
+// Render 实现 Renderer 接口
+func (r *XBinRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	mapper := newCP437Mapper(r.Charset)
+	page := pageColor(r.Background)
+
+	// 调色板以 6 位精度存储，先降低精度再统计颜色
+	counts := map[color.RGBA]int{}
+	for _, c := range grid.Cells {
+		if mapper.byteOf(c) != ' ' {
+			counts[vga6(c.FG)]++
+		}
+		bg := c.BG
+		if bg.A == 0 {
+			bg = page
+		}
+		counts[vga6(bg)]++
+	}
+	colors := medianCut(counts, 16)
+	palette := make([]color.RGBA, len(colors))
+	for i, c := range colors {
+		palette[i] = color.RGBA{vga8(c.R), vga8(c.G), vga8(c.B), 255}
+	}
+	matcher := newANSIPalette(0, palette)
+	nearest := map[color.RGBA]int{}
+	match := func(c color.RGBA) int {
+		i, ok := nearest[c]
+		if !ok {
+			i = matcher.nearest(c)
+			nearest[c] = i
+		}
+		return i
+	}
+
+	var b bytes.Buffer
+	b.WriteString("XBIN\x1A")
+	binary.Write(&b, binary.LittleEndian, uint16(grid.Cols))
+	binary.Write(&b, binary.LittleEndian, uint16(grid.Rows))
+	b.WriteByte(xbinFontHeight)
+	b.WriteByte(xbinFlagPalette | xbinFlagNonBlink)
+	for _, c := range colors {
+		b.Write([]byte{c.R, c.G, c.B})
+	}
+	for i, c := range grid.Cells {
+		if i%grid.Cols == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		bg := c.BG
+		if bg.A == 0 {
+			bg = page
+		}
+		b.Write([]byte{mapper.byteOf(c), byte(match(c.FG) | match(bg)<<4)})
+	}
+	r.SAUCE.writeSAUCE(&b, sauceRecord{dataType: sauceXBin, width: grid.Cols, height: grid.Rows})
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// pageColor 返回没有背景色的单元格使用的背景，background 为 nil 或透明时为黑色
+func pageColor(background color.Color) color.RGBA {
+	if background == nil {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	c := color.RGBAModel.Convert(background).(color.RGBA)
+	if c.A == 0 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	return c
+}
+
+// vga6 将颜色转换为 VGA DAC 的 6 位精度
+func vga6(c color.RGBA) color.RGBA {
+	return color.RGBA{c.R >> 2, c.G >> 2, c.B >> 2, 255}
+}
+
+// vga8 将 6 位颜色分量扩展为 8 位
+func vga8(v uint8) uint8 {
+	return v<<2 | v>>4
+}
+
+// medianCut 用中位切分法从带计数的颜色中选出 n 种颜色，不足 n 种时以黑色补足
+func medianCut(counts map[color.RGBA]int, n int) []color.RGBA {
+	type entry struct {
+		c     color.RGBA
+		count int
+	}
+	entries := make([]entry, 0, len(counts))
+	for c, count := range counts {
+		entries = append(entries, entry{c, count})
+	}
+	key := func(c color.RGBA) int { return int(c.R)<<16 | int(c.G)<<8 | int(c.B) }
+	sort.Slice(entries, func(i, j int) bool { return key(entries[i].c) < key(entries[j].c) })
+
+	channel := func(c color.RGBA, ch int) uint8 { return [3]uint8{c.R, c.G, c.B}[ch] }
+	// widest 返回颜色范围最大的通道及其范围
+	widest := func(box []entry) (int, int) {
+		best, bestRange := 0, -1
+		for ch := 0; ch < 3; ch++ {
+			lo, hi := 255, 0
+			for _, e := range box {
+				v := int(channel(e.c, ch))
+				if v < lo {
+					lo = v
+				}
+				if v > hi {
+					hi = v
+				}
+			}
+			if hi-lo > bestRange {
+				best, bestRange = ch, hi-lo
+			}
+		}
+		return best, bestRange
+	}
+
+	boxes := [][]entry{}
+	if len(entries) > 0 {
+		boxes = append(boxes, entries)
+	}
+	for len(boxes) < n {
+		split, splitRange, splitChannel := -1, 0, 0
+		for i, box := range boxes {
+			if ch, rng := widest(box); len(box) > 1 && rng > splitRange {
+				split, splitRange, splitChannel = i, rng, ch
+			}
+		}
+		if split < 0 {
+			break
+		}
+		box := boxes[split]
+		sort.SliceStable(box, func(i, j int) bool {
+			return channel(box[i].c, splitChannel) < channel(box[j].c, splitChannel)
+		})
+		total := 0
+		for _, e := range box {
+			total += e.count
+		}
+		// 在加权中位数处切分，两侧至少各保留一种颜色
+		cut, sum := 1, 0
+		for i, e := range box[:len(box)-1] {
+			sum += e.count
+			cut = i + 1
+			if sum*2 >= total {
+				break
+			}
+		}
+		boxes[split] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	colors := make([]color.RGBA, 0, n)
+	for _, box := range boxes {
+		var r, g, b, total int
+		for _, e := range box {
+			r += int(e.c.R) * e.count
+			g += int(e.c.G) * e.count
+			b += int(e.c.B) * e.count
+			total += e.count
+		}
+		colors = append(colors, color.RGBA{uint8((r + total/2) / total), uint8((g + total/2) / total), uint8((b + total/2) / total), 255})
+	}
+	for len(colors) < n {
+		colors = append(colors, color.RGBA{0, 0, 0, 255})
+	}
+	return colors
+}
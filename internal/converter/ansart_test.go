@@ -0,0 +1,171 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试 CP437 映射：可直接表示的字符、字符梯度、块元素、盲文和其他字符的阴影替换
+func TestCP437Mapper(t *testing.T) {
+	m := newCP437Mapper([]rune("永和 "))
+	tests := []struct {
+		cell     Cell
+		expected byte
+	}{
+		{Cell{Rune: '@'}, '@'},
+		{Cell{Rune: 'é'}, 0x82},
+		{Cell{Rune: '▀'}, 0xDF},
+		{Cell{Rune: '░'}, 0xB0},
+		{Cell{Rune: '永'}, 0xDB},
+		{Cell{Rune: '和'}, 0xB1},
+		{Cell{Rune: '▘'}, 0xB0},
+		{Cell{Rune: '▚'}, 0xB1},
+		{Cell{Rune: '▙'}, 0xB2},
+		{Cell{Rune: 0x28FF}, 0xDB},
+		{Cell{Rune: 'ж', Brightness: 0.75}, 0xB2},
+		{Cell{Rune: 'ж', Brightness: 0.1}, ' '},
+	}
+	for _, tt := range tests {
+		if got := m.byteOf(tt.cell); got != tt.expected {
+			t.Errorf("byteOf(%q) = %#x, expected %#x", tt.cell.Rune, got, tt.expected)
+		}
+	}
+	if got := encodeCP437("Café ж"); !bytes.Equal(got, []byte{'C', 'a', 'f', 0x82, ' ', '?'}) {
+		t.Errorf("unexpected encoding %v", got)
+	}
+}
+
+// 测试 .ans 输出的 iCE 颜色序列、行尾处理和 SAUCE 记录
+func TestANSArtRenderer(t *testing.T) {
+	grid := NewGrid(2, 3)
+	for i := range grid.Cells {
+		grid.Cells[i] = Cell{Rune: ' '}
+	}
+	grid.Cells[0] = Cell{Rune: 'A', FG: color.RGBA{255, 85, 85, 255}}
+	grid.Cells[1] = Cell{Rune: 'B', FG: color.RGBA{170, 0, 0, 255}, BG: color.RGBA{85, 85, 255, 255}}
+
+	var b bytes.Buffer
+	r := &ANSArtRenderer{SAUCE: SAUCE{Title: "Test", Author: "me", Date: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)}}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.Bytes()
+	body := "\x1b[0m\x1b[1;31mA\x1b[0;5;31;44mB\x1b[0m\r\n\r\n"
+	if len(out) != len(body)+129 || string(out[:len(body)]) != body {
+		t.Fatalf("expected %q followed by SAUCE, got %q", body, out)
+	}
+
+	sauce := out[len(body):]
+	if sauce[0] != 0x1A || string(sauce[1:8]) != "SAUCE00" {
+		t.Fatalf("missing SAUCE header: %q", sauce)
+	}
+	record := sauce[1:]
+	if string(record[7:42]) != "Test"+string(bytes.Repeat([]byte{' '}, 31)) || string(record[42:44]) != "me" || string(record[82:90]) != "20240506" {
+		t.Errorf("unexpected SAUCE text fields %q", record[7:90])
+	}
+	if size := binary.LittleEndian.Uint32(record[90:94]); size != uint32(len(body)) {
+		t.Errorf("expected file size %d, got %d", len(body), size)
+	}
+	if record[94] != 1 || record[95] != 1 || binary.LittleEndian.Uint16(record[96:98]) != 3 || binary.LittleEndian.Uint16(record[98:100]) != 2 {
+		t.Errorf("unexpected SAUCE type or dimensions % x", record[94:100])
+	}
+	if record[105] != 1 || string(bytes.TrimRight(record[106:128], "\x00")) != "IBM VGA" {
+		t.Errorf("unexpected SAUCE flags or font % x", record[105:128])
+	}
+}
+
+// 测试整行的换行：只有 80 列的整行依靠终端自动换行，其他宽度的整行以 CR LF 结束
+func TestANSArtRendererFullRows(t *testing.T) {
+	tests := []struct {
+		cols int
+		crlf bool
+	}{
+		{3, true},
+		{79, true},
+		{80, false},
+		{100, true},
+	}
+	for _, tt := range tests {
+		grid := NewGrid(2, tt.cols)
+		for i := range grid.Cells {
+			grid.Cells[i] = Cell{Rune: '#', FG: color.RGBA{170, 170, 170, 255}}
+		}
+		var b bytes.Buffer
+		if err := (&ANSArtRenderer{}).Render(context.Background(), &b, grid); err != nil {
+			t.Fatalf("%d cols: Render failed: %v", tt.cols, err)
+		}
+		row := strings.Repeat("#", tt.cols)
+		body := "\x1b[0m" + row + row
+		if tt.crlf {
+			body = "\x1b[0m" + row + "\r\n" + row + "\r\n"
+		}
+		if out := b.String(); !strings.HasPrefix(out, body+"\x1a") {
+			t.Errorf("%d cols: expected %q followed by SAUCE, got %q", tt.cols, body, out)
+		}
+	}
+}
+
+// 测试 XBin 输出的文件头、调色板和属性字节
+func TestXBinRenderer(t *testing.T) {
+	grid := NewGrid(1, 2)
+	grid.Cells[0] = Cell{Rune: '#', FG: color.RGBA{200, 100, 0, 255}}
+	grid.Cells[1] = Cell{Rune: '▀', FG: color.RGBA{0, 100, 200, 255}, BG: color.RGBA{200, 100, 0, 255}}
+
+	var b bytes.Buffer
+	if err := (&XBinRenderer{}).Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.Bytes()
+	if string(out[:5]) != "XBIN\x1A" || binary.LittleEndian.Uint16(out[5:7]) != 2 || binary.LittleEndian.Uint16(out[7:9]) != 1 || out[9] != 16 || out[10] != 0x09 {
+		t.Fatalf("unexpected header % x", out[:11])
+	}
+	palette := out[11 : 11+48]
+	data := out[11+48 : 11+48+4]
+	if data[0] != '#' || data[2] != 0xDF {
+		t.Errorf("unexpected characters % x", data)
+	}
+	entry := func(i byte) []byte { return palette[int(i)*3 : int(i)*3+3] }
+	orange, black, blue := []byte{50, 25, 0}, []byte{0, 0, 0}, []byte{0, 25, 50}
+	if !bytes.Equal(entry(data[1]&0xF), orange) || !bytes.Equal(entry(data[1]>>4), black) {
+		t.Errorf("cell 0: unexpected colors % x, % x", entry(data[1]&0xF), entry(data[1]>>4))
+	}
+	if !bytes.Equal(entry(data[3]&0xF), blue) || !bytes.Equal(entry(data[3]>>4), orange) {
+		t.Errorf("cell 1: unexpected colors % x, % x", entry(data[3]&0xF), entry(data[3]>>4))
+	}
+	if len(out) != 11+48+4+129 || out[11+48+4] != 0x1A {
+		t.Errorf("expected SAUCE record after the image data, got %d bytes", len(out))
+	}
+}
+
+// 测试中位切分将相近的颜色合并为加权平均色
+func TestMedianCut(t *testing.T) {
+	counts := map[color.RGBA]int{
+		{60, 0, 0, 255}:   3,
+		{56, 0, 0, 255}:   1,
+		{0, 0, 60, 255}:   2,
+		{0, 0, 58, 255}:   2,
+		{30, 30, 30, 255}: 1,
+	}
+	colors := medianCut(counts, 3)
+	expected := []color.RGBA{{0, 0, 59, 255}, {59, 0, 0, 255}, {30, 30, 30, 255}}
+	if len(colors) != 3 {
+		t.Fatalf("expected 3 colors, got %v", colors)
+	}
+	for _, want := range expected {
+		found := false
+		for _, c := range colors {
+			found = found || c == want
+		}
+		if !found {
+			t.Errorf("expected %v in %v", want, colors)
+		}
+	}
+	if got := medianCut(counts, 8); len(got) != 8 || got[7] != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("expected palette padded with black, got %v", got)
+	}
+}
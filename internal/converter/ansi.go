@@ -0,0 +1,311 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// ColorDepth ANSI 文本使用的颜色数量
+type ColorDepth int
+
+const (
+	// ColorAuto 根据输出目标自动选择
+	ColorAuto ColorDepth = iota
+	// ColorNone 不输出颜色
+	ColorNone
+	// Color16 基本 16 色
+	Color16
+	// Color256 xterm 256 色
+	Color256
+	// ColorTrue 24 位真彩色
+	ColorTrue
+)
+
+// ParseColorDepth 解析颜色数量名称：auto、none、16、256 或 truecolor
+func ParseColorDepth(name string) (ColorDepth, error) {
+	switch name {
+	case "", "auto":
+		return ColorAuto, nil
+	case "none":
+		return ColorNone, nil
+	case "16":
+		return Color16, nil
+	case "256":
+		return Color256, nil
+	case "truecolor", "24bit":
+		return ColorTrue, nil
+	default:
+		return 0, fmt.Errorf("unsupported ANSI color mode: %s", name)
+	}
+}
+
+// DetectColorDepth 按 NO_COLOR、COLORTERM 和 TERM 环境变量判断终端支持的颜色数量
+func DetectColorDepth(getenv func(string) string) ColorDepth {
+	if getenv("NO_COLOR") != "" {
+		return ColorNone
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorTrue
+	}
+	term := getenv("TERM")
+	switch {
+	case term == "" || term == "dumb":
+		return ColorNone
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "direct"):
+		return ColorTrue
+	case strings.Contains(term, "256color"):
+		return Color256
+	default:
+		return Color16
+	}
+}
+
+// stdoutPath 作为输出路径时表示写入标准输出
+const stdoutPath = "-"
+
+// resolveColorDepth 解析 cfg.ANSIColors；auto 在写入标准输出时按环境变量检测，写入文件时使用真彩色
+func resolveColorDepth(cfg *config.Config) (ColorDepth, error) {
+	depth, err := ParseColorDepth(cfg.ANSIColors)
+	if err != nil || depth != ColorAuto {
+		return depth, err
+	}
+	if cfg.OutputPath == stdoutPath {
+		return DetectColorDepth(os.Getenv), nil
+	}
+	return ColorTrue, nil
+}
+
+// textFallback 返回文本模式未指定格式时使用的格式：标准输出是支持颜色的终端时为 ansi，否则为 text
+func textFallback(cfg *config.Config) string {
+	if cfg.OutputPath != stdoutPath || !progress.IsTerminal(os.Stdout) {
+		return "text"
+	}
+	if depth, err := resolveColorDepth(cfg); err != nil || depth == ColorNone {
+		return "text"
+	}
+	return "ansi"
+}
+
+// ANSIRenderer 将字符网格输出为带 ANSI 颜色转义序列的文本
+type ANSIRenderer struct {
+	// Depth 颜色数量，ColorAuto 按真彩色处理
+	Depth ColorDepth
+	// Background 不为 nil 且不透明时作为没有背景色的单元格的背景
+	Background color.Color
+	// Pool 用于并行生成文本，为 nil 时顺序执行
+	Pool *WorkerPool
+}
+
+// Render 实现 Renderer 接口
+// 颜色在每行末尾重置，各行互不依赖，因此按行分块并行生成后按顺序拼接。
+func (r *ANSIRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	depth := r.Depth
+	if depth == ColorAuto {
+		depth = ColorTrue
+	}
+	var page color.RGBA
+	if r.Background != nil {
+		page = color.RGBAModel.Convert(r.Background).(color.RGBA)
+	}
+
+	chunks, err := r.Pool.MapContext(ctx, grid.Rows, r.Pool.rowChunk(grid.Rows), func(start, end int) interface{} {
+		var chunk strings.Builder
+		aw := newANSIWriter(&chunk, depth)
+		for i := start; i < end; i++ {
+			for _, c := range grid.Row(i) {
+				bg := c.BG
+				if bg.A == 0 {
+					bg = page
+				}
+				aw.writeCell(c.Rune, c.FG, bg)
+			}
+			aw.endLine()
+		}
+		return chunk.String()
+	})
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, chunk := range chunks {
+		b.WriteString(chunk.(string))
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// ansiWriter 输出带 ANSI 颜色转义序列的文本，转义序列与当前状态相同时不重复输出
+type ansiWriter struct {
+	b       *strings.Builder
+	palette *ansiPalette
+	depth   ColorDepth
+	// fg, bg 当前生效的 SGR 参数，空字符串表示终端默认颜色
+	fg, bg string
+	// nearest 缓存颜色到调色板索引的匹配结果
+	nearest map[color.RGBA]int
+}
+
+// newANSIWriter 创建写入 b 的 ANSI 输出
+func newANSIWriter(b *strings.Builder, depth ColorDepth) *ansiWriter {
+	w := &ansiWriter{b: b, depth: depth}
+	switch depth {
+	case Color16:
+		w.palette = xterm16
+	case Color256:
+		w.palette = xterm256
+	}
+	if w.palette != nil {
+		w.nearest = map[color.RGBA]int{}
+	}
+	return w
+}
+
+// writeCell 以指定的前景色和背景色输出一个字符，背景色透明时使用终端默认背景。
+// 空格不显示前景色，因此不为空格切换前景色。
+func (w *ansiWriter) writeCell(r rune, fg, bg color.RGBA) {
+	if w.depth != ColorNone {
+		if r != ' ' {
+			if fgSeq := w.sgr(fg, false); fgSeq != w.fg {
+				fmt.Fprintf(w.b, "\x1b[%sm", fgSeq)
+				w.fg = fgSeq
+			}
+		}
+		bgSeq := ""
+		if bg.A != 0 {
+			bgSeq = w.sgr(bg, true)
+		}
+		if bgSeq != w.bg {
+			if bgSeq == "" {
+				w.b.WriteString("\x1b[49m")
+			} else {
+				fmt.Fprintf(w.b, "\x1b[%sm", bgSeq)
+			}
+			w.bg = bgSeq
+		}
+	}
+	w.b.WriteRune(r)
+}
+
+// endLine 重置颜色并换行，避免背景色延伸到行尾
+func (w *ansiWriter) endLine() {
+	if w.fg != "" || w.bg != "" {
+		w.b.WriteString("\x1b[0m")
+		w.fg, w.bg = "", ""
+	}
+	w.b.WriteString("\n")
+}
+
+// sgr 返回以 c 为前景色或背景色的 SGR 参数
+func (w *ansiWriter) sgr(c color.RGBA, background bool) string {
+	c.A = 255
+	switch w.depth {
+	case Color16:
+		i := w.match(c)
+		code := 30 + i
+		if i >= 8 {
+			code = 90 + i - 8
+		}
+		if background {
+			code += 10
+		}
+		return strconv.Itoa(code)
+	case Color256:
+		prefix := "38;5;"
+		if background {
+			prefix = "48;5;"
+		}
+		return prefix + strconv.Itoa(w.match(c))
+	default:
+		prefix := "38;2;"
+		if background {
+			prefix = "48;2;"
+		}
+		return fmt.Sprintf("%s%d;%d;%d", prefix, c.R, c.G, c.B)
+	}
+}
+
+// match 返回调色板中与 c 最接近的颜色的索引
+func (w *ansiWriter) match(c color.RGBA) int {
+	if i, ok := w.nearest[c]; ok {
+		return i
+	}
+	i := w.palette.nearest(c)
+	w.nearest[c] = i
+	return i
+}
+
+// ansiPalette 终端调色板，在 OKLab 空间中按感知距离匹配颜色
+type ansiPalette struct {
+	// first 第一个可选颜色的索引，256 色模式不使用随终端主题变化的前 16 色
+	first  int
+	colors []color.RGBA
+	labs   [][3]float64
+}
+
+// newANSIPalette 创建调色板并预先计算各颜色的 OKLab 坐标
+func newANSIPalette(first int, colors []color.RGBA) *ansiPalette {
+	p := &ansiPalette{first: first, colors: colors, labs: make([][3]float64, len(colors))}
+	for i, c := range colors {
+		p.labs[i] = rgbaToOklab(c)
+	}
+	return p
+}
+
+// nearest 返回与 c 感知距离最小的颜色的索引
+func (p *ansiPalette) nearest(c color.RGBA) int {
+	lab := rgbaToOklab(c)
+	best, bestDist := p.first, -1.0
+	for i := p.first; i < len(p.colors); i++ {
+		dl, da, db := lab[0]-p.labs[i][0], lab[1]-p.labs[i][1], lab[2]-p.labs[i][2]
+		if dist := dl*dl + da*da + db*db; bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// rgbaToOklab 将 8 位 sRGB 颜色转换为 OKLab
+func rgbaToOklab(c color.RGBA) [3]float64 {
+	l, a, b := linearToOklab(srgbToLinearTable[c.R], srgbToLinearTable[c.G], srgbToLinearTable[c.B])
+	return [3]float64{l, a, b}
+}
+
+// xtermColors 返回 xterm 的 256 色调色板：16 个基本色、6x6x6 颜色立方和 24 级灰度
+func xtermColors() []color.RGBA {
+	colors := []color.RGBA{
+		{0, 0, 0, 255}, {205, 0, 0, 255}, {0, 205, 0, 255}, {205, 205, 0, 255},
+		{0, 0, 238, 255}, {205, 0, 205, 255}, {0, 205, 205, 255}, {229, 229, 229, 255},
+		{127, 127, 127, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+		{92, 92, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+	}
+	levels := [6]uint8{0, 95, 135, 175, 215, 255}
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				colors = append(colors, color.RGBA{levels[r], levels[g], levels[b], 255})
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		v := uint8(8 + 10*i)
+		colors = append(colors, color.RGBA{v, v, v, 255})
+	}
+	return colors
+}
+
+var (
+	xterm256 = newANSIPalette(16, xtermColors())
+	xterm16  = newANSIPalette(0, xtermColors()[:16])
+)
@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"context"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// 测试按环境变量检测终端颜色数量
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		expected ColorDepth
+	}{
+		{map[string]string{"COLORTERM": "truecolor", "TERM": "xterm"}, ColorTrue},
+		{map[string]string{"COLORTERM": "24bit"}, ColorTrue},
+		{map[string]string{"TERM": "xterm-256color"}, Color256},
+		{map[string]string{"TERM": "xterm-direct"}, ColorTrue},
+		{map[string]string{"TERM": "vt100"}, Color16},
+		{map[string]string{"TERM": "dumb"}, ColorNone},
+		{map[string]string{}, ColorNone},
+		{map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, ColorNone},
+	}
+	for _, tt := range tests {
+		got := DetectColorDepth(func(key string) string { return tt.env[key] })
+		if got != tt.expected {
+			t.Errorf("%v: expected %d, got %d", tt.env, tt.expected, got)
+		}
+	}
+}
+
+// 测试颜色数量名称的解析
+func TestParseColorDepth(t *testing.T) {
+	for name, expected := range map[string]ColorDepth{"": ColorAuto, "auto": ColorAuto, "none": ColorNone, "16": Color16, "256": Color256, "truecolor": ColorTrue} {
+		got, err := ParseColorDepth(name)
+		if err != nil || got != expected {
+			t.Errorf("ParseColorDepth(%q) = %d, %v; expected %d", name, got, err, expected)
+		}
+	}
+	if _, err := ParseColorDepth("88"); err == nil {
+		t.Error("expected error for unsupported color mode")
+	}
+}
+
+// 测试调色板按感知距离匹配最接近的颜色
+func TestANSIPaletteNearest(t *testing.T) {
+	tests := []struct {
+		palette  *ansiPalette
+		c        color.RGBA
+		expected int
+	}{
+		{xterm16, color.RGBA{255, 0, 0, 255}, 9},
+		{xterm16, color.RGBA{200, 10, 10, 255}, 1},
+		{xterm16, color.RGBA{250, 250, 250, 255}, 15},
+		{xterm16, color.RGBA{10, 10, 10, 255}, 0},
+		{xterm256, color.RGBA{255, 0, 0, 255}, 196},
+		{xterm256, color.RGBA{128, 128, 128, 255}, 244},
+		{xterm256, color.RGBA{0, 0, 0, 255}, 16},
+		{xterm256, color.RGBA{95, 135, 175, 255}, 16 + 36*1 + 6*2 + 3},
+	}
+	for _, tt := range tests {
+		if got := tt.palette.nearest(tt.c); got != tt.expected {
+			t.Errorf("nearest(%v) = %d, expected %d", tt.c, got, tt.expected)
+		}
+	}
+}
+
+// 测试各颜色模式的转义序列，相同颜色的连续字符只输出一次序列
+func TestANSIRenderer(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	grid := NewGrid(1, 4)
+	for i := range grid.Cells {
+		grid.Cells[i] = Cell{Rune: '#', FG: red}
+	}
+	grid.Cells[2].Rune = ' '
+
+	tests := []struct {
+		depth    ColorDepth
+		expected string
+	}{
+		{ColorTrue, "\x1b[38;2;255;0;0m## #\x1b[0m\n"},
+		{Color256, "\x1b[38;5;196m## #\x1b[0m\n"},
+		{Color16, "\x1b[91m## #\x1b[0m\n"},
+		{ColorNone, "## #\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := (&ANSIRenderer{Depth: tt.depth}).Render(context.Background(), &b, grid); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if b.String() != tt.expected {
+			t.Errorf("depth %d: expected %q, got %q", tt.depth, tt.expected, b.String())
+		}
+	}
+}
+
+// 测试 Background 作为没有背景色的单元格的背景，单元格自身的背景色优先
+func TestANSIRendererBackground(t *testing.T) {
+	grid := NewGrid(1, 2)
+	grid.Cells[0] = Cell{Rune: 'a', FG: color.RGBA{255, 255, 255, 255}}
+	grid.Cells[1] = Cell{Rune: 'b', FG: color.RGBA{255, 255, 255, 255}, BG: color.RGBA{0, 0, 255, 255}}
+
+	var b strings.Builder
+	r := &ANSIRenderer{Depth: ColorTrue, Background: color.Black}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	expected := "\x1b[38;2;255;255;255m\x1b[48;2;0;0;0ma\x1b[48;2;0;0;255mb\x1b[0m\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+
+	// 透明背景等同于没有背景
+	b.Reset()
+	r.Background = color.Transparent
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	expected = "\x1b[38;2;255;255;255ma\x1b[48;2;0;0;255mb\x1b[0m\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
@@ -0,0 +1,202 @@
+package converter
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// blockShape 块元素字符的子单元格划分及其字形表
+// 字形表以子单元格掩码为下标，第 row*cols+col 位表示该子单元格使用前景色。
+type blockShape struct {
+	cols, rows int
+	glyphs     []rune
+	masks      map[rune]int
+}
+
+// newBlockShape 创建块元素形状并建立字符到掩码的反查表
+func newBlockShape(cols, rows int, glyphs []rune) *blockShape {
+	masks := make(map[rune]int, len(glyphs))
+	for mask, r := range glyphs {
+		masks[r] = mask
+	}
+	return &blockShape{cols: cols, rows: rows, glyphs: glyphs, masks: masks}
+}
+
+// 半块（U+2580/U+2584）、四分块和六分块（U+1FB00 区块）字符形状
+var (
+	halfBlockShape = newBlockShape(1, 2, []rune{' ', '▀', '▄', '█'})
+
+	quadrantShape = newBlockShape(2, 2, []rune{
+		' ', '▘', '▝', '▀', '▖', '▌', '▞', '▛',
+		'▗', '▚', '▐', '▜', '▄', '▙', '▟', '█',
+	})
+
+	sextantShape = newBlockShape(2, 3, sextantGlyphs())
+)
+
+// sextantGlyphs 生成六分块字形表
+// U+1FB00 区块省略了已存在于其他区块的空白、左半块、右半块和全块。
+func sextantGlyphs() []rune {
+	glyphs := make([]rune, 64)
+	for mask := range glyphs {
+		switch mask {
+		case 0:
+			glyphs[mask] = ' '
+		case 21:
+			glyphs[mask] = '▌'
+		case 42:
+			glyphs[mask] = '▐'
+		case 63:
+			glyphs[mask] = '█'
+		default:
+			offset := mask - 1
+			if mask > 21 {
+				offset--
+			}
+			if mask > 42 {
+				offset--
+			}
+			glyphs[mask] = rune(0x1FB00 + offset)
+		}
+	}
+	return glyphs
+}
+
+// blockSelector 将单元格划分为若干子单元格，为每个单元格选择一个块元素字符及前景、背景两种颜色，
+// 使一个字符可以表示两个或更多像素的颜色。
+type blockSelector struct {
+	shape *blockShape
+}
+
+func (s *blockSelector) selectRunes(src *sampler, layout cellLayout) [][]rune {
+	runes := make([][]rune, layout.numRows)
+	src.pool.Rows(layout.numRows, func(i int) {
+		runes[i] = make([]rune, layout.numCols)
+		for j := range runes[i] {
+			x, y, width, height := layout.cell(i, j)
+			colors := sampleColorGrid(src, x, y, width, height, s.shape.cols, s.shape.rows)
+			runes[i][j] = s.shape.glyphs[bestPartition(colors)]
+		}
+	})
+	return runes
+}
+
+// cellColors 返回块元素字符的前景色和背景色，分别为掩码内外子单元格的平均颜色
+func (s *blockSelector) cellColors(src *sampler, x, y, width, height int, r rune) (fg, bg color.Color) {
+	colors := sampleColorGrid(src, x, y, width, height, s.shape.cols, s.shape.rows)
+	fgColor, bgColor, _ := partitionColors(colors, s.shape.masks[r])
+	return fgColor, bgColor
+}
+
+// cellColor 实现 cellColorer，返回块元素字符的前景色
+func (s *blockSelector) cellColor(src *sampler, x, y, width, height int, r rune) color.Color {
+	fg, _ := s.cellColors(src, x, y, width, height, r)
+	return fg
+}
+
+// blockShapeOf 返回包含块元素字符 r 的形状及其掩码。
+// 同一字符在各形状中的几何形状相同，按半块、四分块、六分块的顺序返回第一个包含它的形状。
+func blockShapeOf(r rune) (shape *blockShape, mask int, ok bool) {
+	for _, shape := range []*blockShape{halfBlockShape, quadrantShape, sextantShape} {
+		if mask, ok := shape.masks[r]; ok {
+			return shape, mask, true
+		}
+	}
+	return nil, 0, false
+}
+
+// draw 在图像上按子单元格直接填充前景色和背景色，不依赖字体中的块元素字形
+func (s *blockShape) draw(dc *gg.Context, x, y, width, height float64, mask int, fg, bg color.Color) {
+	subWidth := width / float64(s.cols)
+	subHeight := height / float64(s.rows)
+	for row := 0; row < s.rows; row++ {
+		for col := 0; col < s.cols; col++ {
+			if mask&(1<<(row*s.cols+col)) != 0 {
+				dc.SetColor(fg)
+			} else {
+				dc.SetColor(bg)
+			}
+			// 边界取整到像素，避免相邻矩形之间出现抗锯齿缝隙
+			x0, x1 := math.Round(x+float64(col)*subWidth), math.Round(x+float64(col+1)*subWidth)
+			y0, y1 := math.Round(y+float64(row)*subHeight), math.Round(y+float64(row+1)*subHeight)
+			dc.DrawRectangle(x0, y0, x1-x0, y1-y0)
+			dc.Fill()
+		}
+	}
+}
+
+// sampleColorGrid 将单元格划分为 cols x rows 个子单元格并返回每个子单元格的平均颜色
+func sampleColorGrid(src *sampler, x, y, width, height, cols, rows int) []color.RGBA {
+	grid := make([]color.RGBA, cols*rows)
+	for row := 0; row < rows; row++ {
+		y0, y1 := subRange(y, height, row, rows)
+		for col := 0; col < cols; col++ {
+			x0, x1 := subRange(x, width, col, cols)
+			grid[row*cols+col] = color.RGBAModel.Convert(src.averageColor(x0, y0, x1-x0, y1-y0)).(color.RGBA)
+		}
+	}
+	return grid
+}
+
+// bestPartition 穷举所有子单元格掩码，返回使两组颜色各自方差之和最小的掩码
+func bestPartition(colors []color.RGBA) int {
+	best := 0
+	bestErr := -1.0
+	for mask := 0; mask < 1<<len(colors); mask++ {
+		_, _, err := partitionColors(colors, mask)
+		if bestErr < 0 || err < bestErr {
+			best, bestErr = mask, err
+		}
+	}
+	return best
+}
+
+// partitionColors 按掩码将子单元格分为前景和背景两组，返回两组的平均颜色和平方误差之和
+// 某一组为空时其颜色取另一组的颜色。
+func partitionColors(colors []color.RGBA, mask int) (fg, bg color.RGBA, sqErr float64) {
+	var sums [2][3]float64
+	var counts [2]float64
+	for k, c := range colors {
+		group := 1
+		if mask&(1<<k) != 0 {
+			group = 0
+		}
+		sums[group][0] += float64(c.R)
+		sums[group][1] += float64(c.G)
+		sums[group][2] += float64(c.B)
+		counts[group]++
+	}
+
+	var means [2]color.RGBA
+	for group := range means {
+		if counts[group] == 0 {
+			continue
+		}
+		means[group] = color.RGBA{
+			uint8(sums[group][0]/counts[group] + 0.5),
+			uint8(sums[group][1]/counts[group] + 0.5),
+			uint8(sums[group][2]/counts[group] + 0.5),
+			255,
+		}
+	}
+	if counts[0] == 0 {
+		means[0] = means[1]
+	}
+	if counts[1] == 0 {
+		means[1] = means[0]
+	}
+
+	for k, c := range colors {
+		mean := means[1]
+		if mask&(1<<k) != 0 {
+			mean = means[0]
+		}
+		dr := float64(c.R) - float64(mean.R)
+		dg := float64(c.G) - float64(mean.G)
+		db := float64(c.B) - float64(mean.B)
+		sqErr += dr*dr + dg*dg + db*db
+	}
+	return means[0], means[1], sqErr
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// 测试六分块字形表跳过了已在其他区块中的字符
+func TestSextantGlyphs(t *testing.T) {
+	glyphs := sextantGlyphs()
+	tests := []struct {
+		mask     int
+		expected rune
+	}{
+		{0, ' '},
+		{1, 0x1FB00},
+		{20, 0x1FB13},
+		{21, '▌'},
+		{22, 0x1FB14},
+		{42, '▐'},
+		{62, 0x1FB3B},
+		{63, '█'},
+	}
+
+	for _, test := range tests {
+		if glyphs[test.mask] != test.expected {
+			t.Errorf("mask %d: expected %U, got %U", test.mask, test.expected, glyphs[test.mask])
+		}
+	}
+}
+
+// 测试块元素选择器的字符和前景、背景色
+func TestBlockSelector(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			if y < 8 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		shape    *blockShape
+		expected rune
+	}{
+		{"halfblock", halfBlockShape, '▀'},
+		{"quadrant", quadrantShape, '▀'},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := &blockSelector{shape: test.shape}
+			runes := selector.selectRunes(newSampler(img, srgbSpace, nil), newCellLayout(img.Bounds(), 1))
+			if runes[0][0] != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, runes[0][0])
+			}
+			fg, bg := selector.cellColors(newSampler(img, srgbSpace, nil), 0, 0, 8, 16, runes[0][0])
+			if fg != red || bg != blue {
+				t.Errorf("expected fg %v and bg %v, got %v and %v", red, blue, fg, bg)
+			}
+		})
+	}
+
+	t.Run("uniform", func(t *testing.T) {
+		selector := &blockSelector{shape: quadrantShape}
+		uniform := generateTestImage(8, 16, red)
+		runes := selector.selectRunes(newSampler(uniform, srgbSpace, nil), newCellLayout(uniform.Bounds(), 1))
+		if runes[0][0] != ' ' {
+			t.Errorf("expected space for uniform cell, got %q", runes[0][0])
+		}
+	})
+}
+
+// 测试 ANSI 输出合并重复的颜色序列
+func TestANSIWriter(t *testing.T) {
+	var b strings.Builder
+	w := newANSIWriter(&b, ColorTrue)
+	red := color.RGBA{255, 0, 0, 255}
+	black := color.RGBA{0, 0, 0, 255}
+
+	w.writeCell('▀', red, black)
+	w.writeCell('▀', red, black)
+	w.writeCell('▄', black, black)
+	w.endLine()
+
+	expected := "\x1b[38;2;255;0;0m\x1b[48;2;0;0;0m▀▀\x1b[38;2;0;0;0m▄\x1b[0m\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
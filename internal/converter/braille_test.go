@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 测试盲文编码的点位与背景色反转
+func TestBrailleSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		background string
+		img        image.Image
+		expected   rune
+	}{
+		{"white on black", "black", generateTestImage(8, 16, color.White), '⣿'},
+		{"black on black", "black", generateTestImage(8, 16, color.Black), '⠀'},
+		{"black on white", "white", generateTestImage(8, 16, color.Black), '⣿'},
+		{"left column", "black", splitImage(8, 16, func(x, y int) bool { return x >= 4 }), '⡇'},
+		{"top row", "black", splitImage(8, 16, func(x, y int) bool { return y >= 4 }), '⠉'},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := newBrailleSelector(&config.Config{Background: test.background})
+			if err != nil {
+				t.Fatalf("newBrailleSelector failed: %v", err)
+			}
+			runes := selector.selectRunes(newSampler(test.img, srgbSpace, nil), newCellLayout(test.img.Bounds(), 1))
+			if runes[0][0] != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, runes[0][0])
+			}
+		})
+	}
+}
+
+// 测试盲文模式只取点亮的点的颜色
+func TestBrailleCellColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			if x < 4 {
+				img.Set(x, y, color.RGBA{255, 255, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 40, 255})
+			}
+		}
+	}
+
+	selector, err := newBrailleSelector(&config.Config{Background: "black"})
+	if err != nil {
+		t.Fatalf("newBrailleSelector failed: %v", err)
+	}
+	runes := selector.selectRunes(newSampler(img, srgbSpace, nil), newCellLayout(img.Bounds(), 1))
+	if runes[0][0] != '⡇' {
+		t.Fatalf("expected left column lit, got %q", runes[0][0])
+	}
+
+	result := foregroundColor(selector, newSampler(img, srgbSpace, nil), 0, 0, 8, 16, runes[0][0])
+	if result != (color.RGBA{255, 255, 0, 255}) {
+		t.Errorf("expected color of lit dots, got %v", result)
+	}
+}
@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// calibrationSize 校准时光栅化字形使用的字号，与输出字号无关以保证结果稳定
+const calibrationSize = 48
+
+// glyphDensity 字符及其墨迹覆盖率
+type glyphDensity struct {
+	char     rune
+	coverage float64
+}
+
+// rampCache 按字体、字符集和级数缓存校准后的字符梯度
+var rampCache = struct {
+	sync.Mutex
+	ramps map[string][]rune
+}{ramps: make(map[string][]rune)}
+
+// calibrateCharset 用字符集对应的字体渲染每个字符并测量覆盖率，
+// 按从浓到淡重新排序字符梯度，使亮度到字符的映射在任意文字下都接近线性。
+// levels 大于 0 时将梯度重采样为 levels 个覆盖率均匀分布的字符。
+func calibrateCharset(cs *charset, levels int) (*charset, error) {
+	key := fmt.Sprintf("%s|%d|%s", cs.font.Path, levels, string(cs.chars))
+
+	rampCache.Lock()
+	defer rampCache.Unlock()
+	if ramp, ok := rampCache.ramps[key]; ok {
+		return &charset{chars: ramp, font: cs.font}, nil
+	}
+
+	densities, err := measureDensities(cs)
+	if err != nil {
+		return nil, err
+	}
+	ramp := sortedRamp(densities)
+	if levels > 0 {
+		ramp = resampleRamp(densities, levels)
+	}
+
+	rampCache.ramps[key] = ramp
+	return &charset{chars: ramp, font: cs.font}, nil
+}
+
+// measureDensities 光栅化字符集中每个字符并测量其覆盖率
+func measureDensities(cs *charset) ([]glyphDensity, error) {
+	face, err := fonts.LoadFace(fonts.FontConfig{Path: cs.font.Path, Size: calibrationSize})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	width, height := fonts.CellSize(face, cs.chars)
+	seen := make(map[rune]bool, len(cs.chars))
+	densities := make([]glyphDensity, 0, len(cs.chars))
+	for _, r := range cs.chars {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		densities = append(densities, glyphDensity{
+			char:     r,
+			coverage: fonts.Coverage(fonts.RasterizeGlyph(face, r, width, height)),
+		})
+	}
+
+	// 按从浓到淡排序，与 SimpleChars/ComplexChars 的方向一致
+	sort.SliceStable(densities, func(i, j int) bool {
+		return densities[i].coverage > densities[j].coverage
+	})
+	return densities, nil
+}
+
+// sortedRamp 返回已排序的字符梯度
+func sortedRamp(densities []glyphDensity) []rune {
+	ramp := make([]rune, len(densities))
+	for i, d := range densities {
+		ramp[i] = d.char
+	}
+	return ramp
+}
+
+// resampleRamp 在最浓与最淡之间取 levels 个均匀分布的覆盖率，
+// 每一级选择覆盖率最接近的字符（同一字符可能占据多个相邻级别）。
+func resampleRamp(densities []glyphDensity, levels int) []rune {
+	if len(densities) == 0 {
+		return nil
+	}
+	if levels == 1 {
+		return []rune{densities[0].char}
+	}
+
+	maxCoverage := densities[0].coverage
+	minCoverage := densities[len(densities)-1].coverage
+	ramp := make([]rune, levels)
+	for i := range ramp {
+		target := maxCoverage - float64(i)*(maxCoverage-minCoverage)/float64(levels-1)
+		best := 0
+		for j, d := range densities {
+			if math.Abs(d.coverage-target) < math.Abs(densities[best].coverage-target) {
+				best = j
+			}
+		}
+		ramp[i] = densities[best].char
+	}
+	return ramp
+}
@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// 测试校准后的字符梯度按覆盖率从浓到淡排列
+func TestCalibrateCharset(t *testing.T) {
+	cs := &charset{chars: []rune(" .:@#"), font: fonts.GetFontConfig("english", 1)}
+
+	calibrated, err := calibrateCharset(cs, 0)
+	if err != nil {
+		t.Fatalf("calibrateCharset failed: %v", err)
+	}
+	if len(calibrated.chars) != 5 {
+		t.Fatalf("expected 5 characters, got %d", len(calibrated.chars))
+	}
+	if calibrated.chars[len(calibrated.chars)-1] != ' ' {
+		t.Errorf("expected space to be the lightest glyph, got %q", calibrated.chars[len(calibrated.chars)-1])
+	}
+	if calibrated.chars[0] == '.' || calibrated.chars[0] == ':' {
+		t.Errorf("expected a dense glyph first, got %q", calibrated.chars[0])
+	}
+
+	densities, err := measureDensities(cs)
+	if err != nil {
+		t.Fatalf("measureDensities failed: %v", err)
+	}
+	for i := 1; i < len(densities); i++ {
+		if densities[i].coverage > densities[i-1].coverage {
+			t.Errorf("densities not sorted at %d: %f > %f", i, densities[i].coverage, densities[i-1].coverage)
+		}
+	}
+}
+
+// 测试重采样后的字符梯度长度与端点
+func TestCalibrateCharsetResample(t *testing.T) {
+	cfg := &config.Config{Language: "english", CharMode: "complex", Scale: 1, Calibrate: true, RampLevels: 16}
+
+	cs, err := resolveCharset(cfg)
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	if len(cs.chars) != 16 {
+		t.Fatalf("expected 16 levels, got %d", len(cs.chars))
+	}
+	if cs.chars[15] != ' ' {
+		t.Errorf("expected space as the lightest level, got %q", cs.chars[15])
+	}
+
+	// 第二次解析应命中缓存并得到相同结果
+	cached, err := resolveCharset(cfg)
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	if string(cached.chars) != string(cs.chars) {
+		t.Errorf("expected cached ramp %q, got %q", string(cs.chars), string(cached.chars))
+	}
+}
+
+// 测试字体缺失时返回错误
+func TestCalibrateCharsetMissingFont(t *testing.T) {
+	cs := &charset{chars: []rune("@. "), font: fonts.FontConfig{Path: "fonts/missing.ttf", Size: 10}}
+	if _, err := calibrateCharset(cs, 0); err == nil {
+		t.Error("expected error for missing font")
+	}
+}
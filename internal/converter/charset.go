@@ -0,0 +1,35 @@
+package converter
+
+import (
+	"github.com/hai119/Go-ASCII-generator/internal/alphabets"
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// charset 字符集及其渲染所用的字体
+type charset struct {
+	chars []rune
+	font  fonts.FontConfig
+}
+
+// resolveCharset 根据配置中的语言和字符模式解析字符集，并选择匹配的字体，FontPath 不为空时使用指定的字体文件
+// 未知的语言或字符集组合会返回错误，而不是回退到 ComplexChars。
+// 启用 Calibrate 时按字形覆盖率重新排序字符梯度。
+func resolveCharset(cfg *config.Config) (*charset, error) {
+	chars, err := alphabets.Lookup(cfg.Language, cfg.CharMode)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &charset{
+		chars: []rune(chars),
+		font:  fonts.GetFontConfig(cfg.Language, cfg.Scale),
+	}
+	if cfg.FontPath != "" {
+		cs.font.Path = cfg.FontPath
+	}
+	if cfg.Calibrate {
+		return calibrateCharset(cs, cfg.RampLevels)
+	}
+	return cs, nil
+}
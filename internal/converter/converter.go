@@ -12,21 +12,6 @@ var (
 	ComplexChars = "$@B%8&WM#*oahkbdpqwmZO0QLCJUYXzcvunxrjft/\\|()1{}[]?-_+~<>i!lI;:,\"^`'. "
 )
 
-// getCharList returns the character set based on the provided mode
-// The mode can either be 'simple' or 'complex', and the corresponding character set will be returned.
-func getCharList(mode string) []rune {
-	// Add extra checks for mode variation
-	if mode == "simple" {
-		// Return the simple character set
-		return []rune(SimpleChars)
-	} else if mode == "complex" {
-		// Return the complex character set
-		return []rune(ComplexChars)
-	}
-	// Default case: return complex if mode is unrecognized
-	return []rune(ComplexChars)
-}
-
 // getBgColor returns the background color based on the provided string
 // It will return white for the string "white", and black for all other inputs.
 func getBgColor(background string) color.Color {
@@ -4,6 +4,10 @@ import (
 	"image"
 	"image/color"
 	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/alphabets"
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
 )
 
 // MockImage is a helper function to generate a simple image for testing
@@ -23,18 +27,28 @@ func MockImageGeneration() image.Image {
 	return img
 }
 
-func TestGetCharList(t *testing.T) {
+func TestResolveCharset(t *testing.T) {
 	tests := []struct {
+		language string
 		mode     string
 		expected []rune
 	}{
-		{"simple", []rune(SimpleChars)},
-		{"complex", []rune(ComplexChars)},
+		{"", "simple", []rune(SimpleChars)},
+		{"", "complex", []rune(ComplexChars)},
+		{"english", "", []rune(ComplexChars)},
+		{"general", "standard", []rune(SimpleChars)},
+		{"english", "standard", []rune(alphabets.ENGLISH["standard"])},
+		{"chinese", "", []rune(alphabets.CHINESE["standard"])},
+		{"japanese", "default", []rune(alphabets.JAPANESE["standard"])},
 	}
 
 	for _, test := range tests {
-		t.Run(test.mode, func(t *testing.T) {
-			result := getCharList(test.mode)
+		t.Run(test.language+"/"+test.mode, func(t *testing.T) {
+			cs, err := resolveCharset(&config.Config{Language: test.language, CharMode: test.mode, Scale: 1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result := cs.chars
 			if len(result) != len(test.expected) {
 				t.Errorf("expected length %d, got %d", len(test.expected), len(result))
 			}
@@ -47,6 +61,43 @@ func TestGetCharList(t *testing.T) {
 	}
 }
 
+func TestResolveCharsetFont(t *testing.T) {
+	cs, err := resolveCharset(&config.Config{Language: "chinese", Scale: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.font != fonts.GetFontConfig("chinese", 1) {
+		t.Errorf("expected chinese font config, got %v", cs.font)
+	}
+	// 英文沿用原先 12 磅乘以缩放比例的 DejaVu 字号
+	cs, err = resolveCharset(&config.Config{Language: "english", Scale: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.font.Size != 24 {
+		t.Errorf("expected english font size 24, got %v", cs.font.Size)
+	}
+}
+
+func TestResolveCharsetUnknown(t *testing.T) {
+	tests := []struct {
+		language string
+		mode     string
+	}{
+		{"klingon", "simple"},
+		{"english", "unknown"},
+		{"chinese", "complex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.language+"/"+test.mode, func(t *testing.T) {
+			if _, err := resolveCharset(&config.Config{Language: test.language, CharMode: test.mode}); err == nil {
+				t.Errorf("expected error for %s/%s", test.language, test.mode)
+			}
+		})
+	}
+}
+
 func TestGetBgColor(t *testing.T) {
 	tests := []struct {
 		background string
@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"math"
+	"math/bits"
+)
+
+// cp437High 代码页 437 中 0x80-0xFF 对应的 Unicode 字符
+var cp437High = []rune(
+	"ÇüéâäàåçêëèïîìÄÅ" +
+		"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ" +
+		"áíóúñÑªº¿⌐¬½¼¡«»" +
+		"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+		"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+		"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+		"αßΓπΣσµτΦΘΩδ∞φε∩" +
+		"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■ ")
+
+// cp437Low 代码页 437 中 0x01-0x1F 的图形字符，解码时使用，编码时不输出这些控制字节
+var cp437Low = []rune("☺☻♥♦♣♠•◘○◙♂♀♪♫☼►◄↕‼¶§▬↨↑↓→←∟↔▲▼")
+
+// cp437Shades 按覆盖率从低到高排列的 CP437 阴影字符：空格、░、▒、▓、█
+var cp437Shades = [5]byte{' ', 0xB0, 0xB1, 0xB2, 0xDB}
+
+// cp437Table Unicode 字符到 CP437 字节的映射，只包含可打印字符
+var cp437Table = func() map[rune]byte {
+	table := make(map[rune]byte, 95+len(cp437High))
+	for b := 0x20; b < 0x7F; b++ {
+		table[rune(b)] = byte(b)
+	}
+	for i, r := range cp437High {
+		table[r] = byte(0x80 + i)
+	}
+	// 外形相同的字符
+	table['β'] = 0xE1
+	table['μ'] = 0xE6
+	table['•'] = 0xF9
+	return table
+}()
+
+// cp437Mapper 将网格中的字符映射为 CP437 字节。
+// CP437 中没有的字符按覆盖率替换为阴影字符：字符梯度中的字符按其在梯度中的位置，
+// 块元素和盲文字符按前景覆盖的子单元格比例，其他字符按单元格亮度。
+type cp437Mapper struct {
+	ramp map[rune]float64
+}
+
+// newCP437Mapper 按字符梯度创建映射，charset 按亮度从高到低排列
+func newCP437Mapper(charset []rune) *cp437Mapper {
+	m := &cp437Mapper{ramp: make(map[rune]float64, len(charset))}
+	for i, r := range charset {
+		coverage := 1.0
+		if len(charset) > 1 {
+			coverage = 1 - float64(i)/float64(len(charset)-1)
+		}
+		m.ramp[r] = coverage
+	}
+	return m
+}
+
+// byteOf 返回单元格字符的 CP437 字节
+func (m *cp437Mapper) byteOf(c Cell) byte {
+	if b, ok := cp437Table[c.Rune]; ok {
+		return b
+	}
+	coverage, ok := m.ramp[c.Rune]
+	switch {
+	case ok:
+	case c.Rune >= brailleBase && c.Rune < brailleBase+0x100:
+		coverage = float64(bits.OnesCount(uint(c.Rune-brailleBase))) / 8
+	default:
+		if shape, mask, ok := blockShapeOf(c.Rune); ok {
+			coverage = float64(bits.OnesCount(uint(mask))) / float64(shape.cols*shape.rows)
+		} else {
+			coverage = c.Brightness
+		}
+	}
+	level := int(math.Round(coverage * float64(len(cp437Shades)-1)))
+	if level < 0 {
+		level = 0
+	} else if level >= len(cp437Shades) {
+		level = len(cp437Shades) - 1
+	}
+	return cp437Shades[level]
+}
+
+// decodeCP437 将 CP437 字节解码为字符。
+// 终端控制字节（BEL、BS、TAB、LF、CR、EOF 和 ESC）保持不变，其他控制字节解码为图形字符。
+func decodeCP437(data []byte) []rune {
+	out := make([]rune, len(data))
+	for i, b := range data {
+		switch {
+		case b == 0:
+			out[i] = ' '
+		case b == 0x07 || b == 0x08 || b == 0x09 || b == 0x0A || b == 0x0D || b == 0x1A || b == 0x1B:
+			out[i] = rune(b)
+		case b < 0x20:
+			out[i] = cp437Low[b-1]
+		case b == 0x7F:
+			out[i] = '⌂'
+		case b < 0x80:
+			out[i] = rune(b)
+		default:
+			out[i] = cp437High[b-0x80]
+		}
+	}
+	return out
+}
+
+// encodeCP437 将字符串编码为 CP437，无法表示的字符替换为 '?'
+func encodeCP437(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := cp437Table[r]
+		if !ok {
+			b = '?'
+		}
+		out = append(out, b)
+	}
+	return out
+}
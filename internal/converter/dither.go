@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+)
+
+// ditherWeight 误差扩散核中的一项：相对当前像素的偏移及权重
+type ditherWeight struct {
+	dx, dy int
+	weight float64
+}
+
+// ditherKernel 误差扩散核，权重之和除以 divisor 为扩散出去的误差比例
+type ditherKernel struct {
+	weights []ditherWeight
+	divisor float64
+}
+
+// ditherKernels 支持的误差扩散算法
+var ditherKernels = map[string]*ditherKernel{
+	"floyd-steinberg": {
+		weights: []ditherWeight{
+			{1, 0, 7},
+			{-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+		},
+		divisor: 16,
+	},
+	// Atkinson 只扩散 3/4 的误差，对比度更高
+	"atkinson": {
+		weights: []ditherWeight{
+			{1, 0, 1}, {2, 0, 1},
+			{-1, 1, 1}, {0, 1, 1}, {1, 1, 1},
+			{0, 2, 1},
+		},
+		divisor: 8,
+	},
+	"jjn": {
+		weights: []ditherWeight{
+			{1, 0, 7}, {2, 0, 5},
+			{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+			{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+		},
+		divisor: 48,
+	},
+}
+
+// bayerMatrix 8x8 Bayer 有序抖动阈值矩阵
+var bayerMatrix = [8][8]float64{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// quantizer 将 [0, 1] 范围的亮度网格量化为 levels 级的下标，可选抖动以减少渐变中的色带
+type quantizer struct {
+	method string
+	kernel *ditherKernel
+}
+
+// newQuantizer 根据抖动方法创建量化器，方法为空或 "none" 时按亮度截断取级别，与不抖动的字符梯度一致
+func newQuantizer(method string) (*quantizer, error) {
+	switch method {
+	case "", "none":
+		return &quantizer{method: "none"}, nil
+	case "bayer":
+		return &quantizer{method: method}, nil
+	}
+	kernel, ok := ditherKernels[method]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dither method: %s", method)
+	}
+	return &quantizer{method: method, kernel: kernel}, nil
+}
+
+// dithered 返回是否抖动
+func (q *quantizer) dithered() bool {
+	return q.method != "none"
+}
+
+// quantize 量化 width x height 的亮度网格，返回每个位置的级别下标 [0, levels-1]
+// 不抖动时截断取级别，误差扩散取最近的级别。
+// 误差扩散按蛇形顺序扫描（奇数行从右向左），结果是确定的。
+func (q *quantizer) quantize(values []float64, width, height, levels int) []int {
+	indices := make([]int, len(values))
+	if levels <= 1 {
+		return indices
+	}
+	scale := float64(levels - 1)
+
+	switch {
+	case q.kernel != nil:
+		q.diffuse(values, indices, width, height, scale)
+	case q.method == "bayer":
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				threshold := (bayerMatrix[y%8][x%8] + 0.5) / 64
+				indices[y*width+x] = clampInt(int(values[y*width+x]*scale+threshold), 0, levels-1)
+			}
+		}
+	default:
+		for k, v := range values {
+			indices[k] = clampInt(int(v*scale), 0, levels-1)
+		}
+	}
+	return indices
+}
+
+// diffuse 对网格执行误差扩散，每个位置取最近的级别并把量化误差按扩散核分配给未处理的邻居
+func (q *quantizer) diffuse(values []float64, indices []int, width, height int, scale float64) {
+	buf := make([]float64, len(values))
+	copy(buf, values)
+
+	levels := int(scale) + 1
+	for y := 0; y < height; y++ {
+		// 蛇形扫描：奇数行反向，扩散核水平镜像
+		dir := 1
+		x0, x1 := 0, width
+		if y%2 == 1 {
+			dir = -1
+			x0, x1 = width-1, -1
+		}
+
+		for x := x0; x != x1; x += dir {
+			k := y*width + x
+			index := clampInt(int(math.Round(buf[k]*scale)), 0, levels-1)
+			indices[k] = index
+
+			quantErr := buf[k] - float64(index)/scale
+			for _, w := range q.kernel.weights {
+				nx, ny := x+w.dx*dir, y+w.dy
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				buf[ny*width+nx] += quantErr * w.weight / q.kernel.divisor
+			}
+		}
+	}
+}
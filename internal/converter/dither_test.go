@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"math"
+	"testing"
+)
+
+// 生成水平渐变的亮度网格
+func gradientValues(width, height int) []float64 {
+	values := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			values[y*width+x] = float64(x) / float64(width-1)
+		}
+	}
+	return values
+}
+
+// 测试不抖动时与基线的字符梯度一致，按 int(v*(levels-1)) 截断取级别
+func TestQuantizeNone(t *testing.T) {
+	q, err := newQuantizer("none")
+	if err != nil {
+		t.Fatalf("newQuantizer failed: %v", err)
+	}
+
+	indices := q.quantize([]float64{0, 0.1, 0.24, 0.26, 0.74, 0.9999, 1}, 7, 1, 5)
+	expected := []int{0, 0, 0, 1, 2, 3, 4}
+	for i := range expected {
+		if indices[i] != expected[i] {
+			t.Errorf("index %d: expected %d, got %d", i, expected[i], indices[i])
+		}
+	}
+}
+
+// 测试各抖动方法保持平均亮度且结果确定
+func TestQuantizeDither(t *testing.T) {
+	const width, height, levels = 64, 32, 4
+	values := gradientValues(width, height)
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	for _, method := range []string{"floyd-steinberg", "atkinson", "jjn", "bayer"} {
+		t.Run(method, func(t *testing.T) {
+			q, err := newQuantizer(method)
+			if err != nil {
+				t.Fatalf("newQuantizer failed: %v", err)
+			}
+
+			indices := q.quantize(values, width, height, levels)
+			var quantized float64
+			for _, index := range indices {
+				if index < 0 || index >= levels {
+					t.Fatalf("index %d out of range", index)
+				}
+				quantized += float64(index) / float64(levels-1)
+			}
+			quantized /= float64(len(indices))
+			if math.Abs(quantized-mean) > 0.03 {
+				t.Errorf("expected mean brightness around %f, got %f", mean, quantized)
+			}
+
+			again := q.quantize(values, width, height, levels)
+			for i := range indices {
+				if indices[i] != again[i] {
+					t.Fatalf("dithering is not deterministic at %d", i)
+				}
+			}
+		})
+	}
+}
+
+// 测试 Bayer 抖动在 50% 灰度上点亮一半的位置
+func TestQuantizeBayerHalf(t *testing.T) {
+	q, err := newQuantizer("bayer")
+	if err != nil {
+		t.Fatalf("newQuantizer failed: %v", err)
+	}
+
+	values := make([]float64, 64)
+	for i := range values {
+		values[i] = 0.5
+	}
+	var lit int
+	for _, index := range q.quantize(values, 8, 8, 2) {
+		lit += index
+	}
+	if lit != 32 {
+		t.Errorf("expected 32 lit positions, got %d", lit)
+	}
+}
+
+// 测试未知的抖动方法返回错误
+func TestNewQuantizerUnknown(t *testing.T) {
+	if _, err := newQuantizer("unknown"); err == nil {
+		t.Error("expected error for unknown dither method")
+	}
+}
@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/fogleman/gg"
+
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// drawOptions 绘制字符网格所需的参数
+type drawOptions struct {
+	font       fonts.FontConfig
+	background color.Color
+	// mono 不为 nil 时所有字符使用该颜色绘制，否则使用单元格颜色
+	mono color.Color
+}
+
+// drawGrid 将字符网格绘制为与源图像同尺寸的图像。
+// 网格按行分块，由工作池并行绘制到各自的透明画布上，再按顺序叠加到背景上；
+// 画布上下各留出余量，超出单元格的字形不会在分块边界处被截断。
+// ctx 取消后剩余的分块不再绘制并返回 ctx 的错误。
+func drawGrid(ctx context.Context, pool *WorkerPool, grid *Grid, opts drawOptions) (image.Image, error) {
+	margin := int(math.Ceil(math.Max(grid.CellHeight, opts.font.Size)))
+
+	type band struct {
+		canvas *image.RGBA
+		top    int
+		err    error
+	}
+	bands, err := pool.MapContext(ctx, grid.Rows, pool.rowChunk(grid.Rows), func(start, end int) interface{} {
+		top := int(float64(start)*grid.CellHeight) - margin
+		bottom := int(math.Ceil(float64(end)*grid.CellHeight)) + margin
+		canvas := image.NewRGBA(image.Rect(0, 0, grid.Width, bottom-top))
+		dc := gg.NewContextForRGBA(canvas)
+		if err := fonts.LoadFont(dc, opts.font); err != nil {
+			return band{err: err}
+		}
+		for i := start; i < end; i++ {
+			drawRow(dc, grid, i, float64(top), opts.mono)
+		}
+		return band{canvas: canvas, top: top}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, grid.Width, grid.Height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(opts.background), image.Point{}, draw.Src)
+	for _, value := range bands {
+		b := value.(band)
+		if b.err != nil {
+			return nil, b.err
+		}
+		r := b.canvas.Bounds().Add(image.Pt(0, b.top))
+		draw.Draw(dst, r, b.canvas, image.Point{}, draw.Over)
+	}
+	return dst, nil
+}
+
+// drawRow 绘制第 i 行的字符，originY 为画布顶端在输出图像中的纵坐标
+func drawRow(dc *gg.Context, grid *Grid, i int, originY float64, mono color.Color) {
+	for j, c := range grid.Row(i) {
+		x := float64(j) * grid.CellWidth
+		y := float64(i)*grid.CellHeight - originY
+
+		// 单色输出使用固定颜色绘制字符
+		if mono != nil {
+			dc.SetColor(mono)
+			dc.DrawStringAnchored(string(c.Rune), x, y+grid.CellHeight/2, 0, 0.5)
+			continue
+		}
+
+		if c.BG.A != 0 {
+			// 块元素字符按子单元格直接填充前景色和背景色
+			if shape, mask, ok := blockShapeOf(c.Rune); ok {
+				shape.draw(dc, x, y, grid.CellWidth, grid.CellHeight, mask, c.FG, c.BG)
+				continue
+			}
+			dc.SetColor(c.BG)
+			dc.DrawRectangle(x, y, grid.CellWidth, grid.CellHeight)
+			dc.Fill()
+		}
+
+		// 使用单元格颜色绘制字符
+		dc.SetColor(c.FG)
+		dc.DrawStringAnchored(string(c.Rune), x, y+grid.CellHeight/2, 0, 0.5)
+	}
+}
@@ -0,0 +1,185 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// defaultEdgeThreshold 未配置时使用的边缘阈值（归一化梯度幅值）
+const defaultEdgeThreshold = 0.25
+
+// 方向字形的下标：水平、底部水平、斜向右上、竖直、斜向右下
+const (
+	edgeHorizontal = iota
+	edgeBottom
+	edgeRising
+	edgeVertical
+	edgeFalling
+)
+
+// edgeGlyphSets 可选的方向字形集合
+var edgeGlyphSets = map[string][5]rune{
+	"ascii": {'-', '_', '/', '|', '\\'},
+	"box":   {'─', '▁', '╱', '│', '╲'},
+}
+
+// gradientField 图像的 Sobel 梯度场，gx 和 gy 按相对 min 的坐标存放
+type gradientField struct {
+	min           image.Point
+	width, height int
+	gx, gy        []float64
+}
+
+// newGradientField 计算图像亮度的 Sobel 梯度，梯度按阶跃边缘的最大响应归一化
+func newGradientField(src *sampler) *gradientField {
+	bounds := src.bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// 预先计算亮度平面，边界外的像素按最近像素处理
+	luma := make([]float64, width*height)
+	src.pool.Rows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			luma[y*width+x] = src.brightness(bounds.Min.X+x, bounds.Min.Y+y, 1, 1)
+		}
+	})
+	at := func(x, y int) float64 {
+		x = clampInt(x, 0, width-1)
+		y = clampInt(y, 0, height-1)
+		return luma[y*width+x]
+	}
+
+	field := &gradientField{
+		min:    bounds.Min,
+		width:  width,
+		height: height,
+		gx:     make([]float64, width*height),
+		gy:     make([]float64, width*height),
+	}
+	src.pool.Rows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			gx := (at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1)) -
+				(at(x-1, y-1) + 2*at(x-1, y) + at(x-1, y+1))
+			gy := (at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1)) -
+				(at(x-1, y-1) + 2*at(x, y-1) + at(x+1, y-1))
+			field.gx[y*width+x] = gx / 4
+			field.gy[y*width+x] = gy / 4
+		}
+	})
+	return field
+}
+
+// edgeSelector 在梯度较强的单元格中按局部边缘方向选择方向字形，
+// 平坦的单元格仍使用亮度梯度选择字符。
+type edgeSelector struct {
+	ramp      *brightnessSelector
+	threshold float64
+	glyphs    [5]rune
+}
+
+// newEdgeSelector 根据配置创建边缘方向选择器
+func newEdgeSelector(cs *charset, cfg *config.Config) (*edgeSelector, error) {
+	name := cfg.EdgeGlyphs
+	if name == "" {
+		name = "ascii"
+	}
+	glyphs, ok := edgeGlyphSets[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported edge glyphs: %s", cfg.EdgeGlyphs)
+	}
+
+	ramp, err := newBrightnessSelector(cs, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := cfg.EdgeThreshold
+	if threshold <= 0 {
+		threshold = defaultEdgeThreshold
+	}
+
+	return &edgeSelector{
+		ramp:      ramp,
+		threshold: threshold,
+		glyphs:    glyphs,
+	}, nil
+}
+
+func (s *edgeSelector) selectRunes(src *sampler, layout cellLayout) [][]rune {
+	field := newGradientField(src)
+
+	// 先按亮度梯度选择全部单元格，再用方向字形覆盖边缘单元格
+	runes := s.ramp.selectRunes(src, layout)
+	src.pool.Rows(layout.numRows, func(i int) {
+		for j := range runes[i] {
+			x, y, width, height := layout.cell(i, j)
+			if glyph, ok := s.edgeGlyph(field, x, y, width, height); ok {
+				runes[i][j] = glyph
+			}
+		}
+	})
+	return runes
+}
+
+// edgeGlyph 统计单元格内幅值超过阈值的边缘像素，像素足够多时
+// 用结构张量求出主方向并返回对应的方向字形。
+func (s *edgeSelector) edgeGlyph(field *gradientField, x, y, width, height int) (rune, bool) {
+	var jxx, jyy, jxy, sumY float64
+	var count int
+	x0, y0 := maxInt(x, field.min.X), maxInt(y, field.min.Y)
+	x1, y1 := minInt(x+width, field.min.X+field.width), minInt(y+height, field.min.Y+field.height)
+	for cy := y0; cy < y1; cy++ {
+		for cx := x0; cx < x1; cx++ {
+			i := (cy-field.min.Y)*field.width + cx - field.min.X
+			gx, gy := field.gx[i], field.gy[i]
+			if math.Hypot(gx, gy) < s.threshold {
+				continue
+			}
+			jxx += gx * gx
+			jyy += gy * gy
+			jxy += gx * gy
+			sumY += float64(cy - y)
+			count++
+		}
+	}
+
+	// 至少需要一条贯穿单元格的线所对应的像素数
+	minCount := width
+	if height < minCount {
+		minCount = height
+	}
+	if count == 0 || count < minCount {
+		return 0, false
+	}
+
+	// 图像坐标 y 轴向下，换算为 y 轴向上的角度后，边缘方向与梯度方向垂直
+	gradientAngle := 0.5 * math.Atan2(-2*jxy, jxx-jyy)
+	edgeAngle := math.Mod(gradientAngle*180/math.Pi+90+180, 180)
+
+	switch {
+	case edgeAngle < 22.5 || edgeAngle >= 157.5:
+		if sumY/float64(count) >= 0.75*float64(height) {
+			return s.glyphs[edgeBottom], true
+		}
+		return s.glyphs[edgeHorizontal], true
+	case edgeAngle < 67.5:
+		return s.glyphs[edgeRising], true
+	case edgeAngle < 112.5:
+		return s.glyphs[edgeVertical], true
+	default:
+		return s.glyphs[edgeFalling], true
+	}
+}
+
+// clampInt 将整数限制在 [lo, hi] 范围内
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 生成按 dark(x, y) 划分黑白区域的测试图像
+func splitImage(width, height int, dark func(x, y int) bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if dark(x, y) {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return img
+}
+
+// 测试边缘方向到方向字形的映射
+func TestEdgeSelector(t *testing.T) {
+	cs := &charset{chars: []rune(SimpleChars)}
+
+	tests := []struct {
+		name     string
+		glyphs   string
+		dark     func(x, y int) bool
+		expected rune
+	}{
+		{"vertical", "ascii", func(x, y int) bool { return x < 40 }, '|'},
+		{"horizontal", "ascii", func(x, y int) bool { return y < 80 }, '-'},
+		{"bottom", "ascii", func(x, y int) bool { return y < 150 }, '_'},
+		{"rising", "ascii", func(x, y int) bool { return 2*x+y < 160 }, '/'},
+		{"falling", "ascii", func(x, y int) bool { return 2*x < y }, '\\'},
+		{"box vertical", "box", func(x, y int) bool { return x < 40 }, '│'},
+		{"flat", "ascii", func(x, y int) bool { return true }, '@'},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := newEdgeSelector(cs, &config.Config{EdgeGlyphs: test.glyphs})
+			if err != nil {
+				t.Fatalf("newEdgeSelector failed: %v", err)
+			}
+			img := splitImage(80, 160, test.dark)
+			runes := selector.selectRunes(newSampler(img, srgbSpace, nil), newCellLayout(img.Bounds(), 1))
+			if runes[0][0] != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, runes[0][0])
+			}
+		})
+	}
+}
+
+// 测试阈值高于最大梯度时回退到亮度梯度
+func TestEdgeSelectorThreshold(t *testing.T) {
+	cs := &charset{chars: []rune(SimpleChars)}
+	selector, err := newEdgeSelector(cs, &config.Config{EdgeThreshold: 2})
+	if err != nil {
+		t.Fatalf("newEdgeSelector failed: %v", err)
+	}
+
+	img := splitImage(80, 160, func(x, y int) bool { return x < 40 })
+	runes := selector.selectRunes(newSampler(img, srgbSpace, nil), newCellLayout(img.Bounds(), 1))
+	if runes[0][0] == '|' {
+		t.Error("expected brightness fallback above threshold")
+	}
+}
+
+// 测试起点不为 (0, 0) 的子图像与同样内容的整幅图像得到相同的梯度场和方向字形
+func TestEdgeSelectorSubImage(t *testing.T) {
+	cs := &charset{chars: []rune(SimpleChars)}
+	selector, err := newEdgeSelector(cs, &config.Config{})
+	if err != nil {
+		t.Fatalf("newEdgeSelector failed: %v", err)
+	}
+
+	full := splitImage(200, 300, func(x, y int) bool { return x < 100 })
+	sub := full.(*image.RGBA).SubImage(image.Rect(60, 70, 140, 230))
+	want := newGradientField(newSampler(splitImage(80, 160, func(x, y int) bool { return x < 40 }), srgbSpace, nil))
+	got := newGradientField(newSampler(sub, srgbSpace, nil))
+	if got.width != want.width || got.height != want.height {
+		t.Fatalf("field size %dx%d, want %dx%d", got.width, got.height, want.width, want.height)
+	}
+	for i := range want.gx {
+		if got.gx[i] != want.gx[i] || got.gy[i] != want.gy[i] {
+			t.Fatalf("gradient %d = (%v, %v), want (%v, %v)", i, got.gx[i], got.gy[i], want.gx[i], want.gy[i])
+		}
+	}
+	if r, ok := selector.edgeGlyph(got, 60, 70, 80, 160); !ok || r != '|' {
+		t.Errorf("expected '|', got %q, %v", r, ok)
+	}
+}
+
+// 测试未知的方向字形集合返回错误
+func TestEdgeSelectorUnknownGlyphs(t *testing.T) {
+	cs := &charset{chars: []rune(SimpleChars)}
+	if _, err := newEdgeSelector(cs, &config.Config{EdgeGlyphs: "unknown"}); err == nil {
+		t.Error("expected error for unknown edge glyphs")
+	}
+}
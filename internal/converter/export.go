@@ -0,0 +1,385 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// GridSchemaVersion 导出格式的版本，字段发生不兼容的变化时递增
+const GridSchemaVersion = 1
+
+// gridMagic 二进制导出格式的文件头
+var gridMagic = []byte("ASCG")
+
+// 导入的网格中行数和列数各自的上限，以及单元格总数的上限
+const (
+	maxGridSide  = 1 << 16
+	maxGridCells = 1 << 26
+	// gridCellPrealloc 读取二进制格式时预先分配的单元格数上限
+	gridCellPrealloc = 1 << 12
+)
+
+// GridFile 导出的字符网格，包含网格尺寸、字符集、字体、每个单元格的数据和生成网格时的设置
+type GridFile struct {
+	Version    int     `json:"version"`
+	Rows       int     `json:"rows"`
+	Cols       int     `json:"cols"`
+	CellWidth  float64 `json:"cell_width"`
+	CellHeight float64 `json:"cell_height"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	// Charset 字符梯度，按亮度从高到低排列
+	Charset string   `json:"charset"`
+	Font    GridFont `json:"font"`
+	// Settings 生成网格时使用的设置
+	Settings *GridSettings `json:"settings,omitempty"`
+	// Cells 按行排列的单元格，二进制格式中单独编码
+	Cells []GridCell `json:"cells"`
+}
+
+// GridFont 生成网格时使用的字体
+type GridFont struct {
+	Path string  `json:"path"`
+	Size float64 `json:"size"`
+}
+
+// GridSettings 生成网格时影响分析结果的设置，不包含输入输出等文件路径
+type GridSettings struct {
+	Mode          string            `json:"mode"`
+	Cols          int               `json:"cols"`
+	Scale         float64           `json:"scale"`
+	Background    string            `json:"background"`
+	Language      string            `json:"language"`
+	CharMode      string            `json:"char_mode"`
+	Calibrate     bool              `json:"calibrate"`
+	RampLevels    int               `json:"ramp_levels"`
+	GlyphMode     string            `json:"glyph_mode"`
+	EdgeThreshold float64           `json:"edge_threshold"`
+	EdgeGlyphs    string            `json:"edge_glyphs"`
+	Dither        string            `json:"dither"`
+	ColorSpace    string            `json:"color_space"`
+	Tone          config.ToneConfig `json:"tone"`
+}
+
+// newGridSettings 从配置中取出生成网格的设置，cfg 为 nil 时返回 nil
+func newGridSettings(cfg *config.Config) *GridSettings {
+	if cfg == nil {
+		return nil
+	}
+	return &GridSettings{
+		Mode:          cfg.Mode,
+		Cols:          cfg.NumCols,
+		Scale:         cfg.Scale,
+		Background:    cfg.Background,
+		Language:      cfg.Language,
+		CharMode:      cfg.CharMode,
+		Calibrate:     cfg.Calibrate,
+		RampLevels:    cfg.RampLevels,
+		GlyphMode:     cfg.GlyphMode,
+		EdgeThreshold: cfg.EdgeThreshold,
+		EdgeGlyphs:    cfg.EdgeGlyphs,
+		Dither:        cfg.Dither,
+		ColorSpace:    cfg.ColorSpace,
+		Tone:          cfg.Tone,
+	}
+}
+
+// apply 返回 cfg 的副本，其中生成网格的设置替换为 s 中的值，重新导出时保留原有的设置
+func (s *GridSettings) apply(cfg *config.Config) *config.Config {
+	out := &config.Config{}
+	if cfg != nil {
+		*out = *cfg
+	}
+	out.Mode, out.NumCols, out.Scale = s.Mode, s.Cols, s.Scale
+	out.Background, out.Language, out.CharMode = s.Background, s.Language, s.CharMode
+	out.Calibrate, out.RampLevels = s.Calibrate, s.RampLevels
+	out.GlyphMode, out.EdgeThreshold, out.EdgeGlyphs = s.GlyphMode, s.EdgeThreshold, s.EdgeGlyphs
+	out.Dither, out.ColorSpace, out.Tone = s.Dither, s.ColorSpace, s.Tone
+	return out
+}
+
+// GridCell 导出的单元格，颜色为 #rrggbb，BG 为空表示没有背景色
+type GridCell struct {
+	Rune       string  `json:"rune"`
+	FG         string  `json:"fg"`
+	BG         string  `json:"bg,omitempty"`
+	Brightness float64 `json:"brightness"`
+}
+
+// NewGridFile 由字符网格和渲染参数中的字符集、字体和配置创建导出数据
+func NewGridFile(grid *Grid, opts RenderOptions) *GridFile {
+	f := &GridFile{
+		Version:    GridSchemaVersion,
+		Rows:       grid.Rows,
+		Cols:       grid.Cols,
+		CellWidth:  grid.CellWidth,
+		CellHeight: grid.CellHeight,
+		Width:      grid.Width,
+		Height:     grid.Height,
+		Charset:    string(opts.Charset),
+		Font:       GridFont{Path: opts.Font.Path, Size: opts.Font.Size},
+		Settings:   newGridSettings(opts.Config),
+		Cells:      make([]GridCell, len(grid.Cells)),
+	}
+	for i, c := range grid.Cells {
+		f.Cells[i] = GridCell{Rune: string(c.Rune), FG: hexColor(c.FG), Brightness: c.Brightness}
+		if c.BG.A != 0 {
+			f.Cells[i].BG = hexColor(c.BG)
+		}
+	}
+	return f
+}
+
+// Grid 将导出数据转换回字符网格
+func (f *GridFile) Grid() (*Grid, error) {
+	if f.Version < 1 || f.Version > GridSchemaVersion {
+		return nil, fmt.Errorf("unsupported grid schema version: %d", f.Version)
+	}
+	if err := checkGridSize(f.Rows, f.Cols); err != nil {
+		return nil, fmt.Errorf("invalid grid: %v", err)
+	}
+	if len(f.Cells) != f.Rows*f.Cols {
+		return nil, fmt.Errorf("invalid grid: %d cells for %dx%d", len(f.Cells), f.Cols, f.Rows)
+	}
+	grid := NewGrid(f.Rows, f.Cols)
+	grid.CellWidth, grid.CellHeight = f.CellWidth, f.CellHeight
+	grid.Width, grid.Height = f.Width, f.Height
+	for i, c := range f.Cells {
+		runes := []rune(c.Rune)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("invalid grid: cell %d has rune %q", i, c.Rune)
+		}
+		fg, err := parseHexColor(c.FG)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid: cell %d: %v", i, err)
+		}
+		var bg color.RGBA
+		if c.BG != "" {
+			if bg, err = parseHexColor(c.BG); err != nil {
+				return nil, fmt.Errorf("invalid grid: cell %d: %v", i, err)
+			}
+		}
+		grid.Cells[i] = Cell{Rune: runes[0], FG: fg, BG: bg, Brightness: c.Brightness}
+	}
+	return grid, nil
+}
+
+// GridJSONRenderer 将字符网格导出为 JSON
+type GridJSONRenderer struct {
+	Options RenderOptions
+}
+
+// Render 实现 Renderer 接口
+func (r *GridJSONRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(NewGridFile(grid, r.Options)); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// GridBinaryRenderer 将字符网格导出为紧凑的二进制格式：
+// 文件头 "ASCG"，uvarint 长度的 JSON 元数据（不含单元格），随后依次为每个单元格的
+// uvarint 字符码、前景色 RGB、背景标志字节（1 时跟随背景色 RGB）和 float32 亮度，多字节数值均为小端序。
+type GridBinaryRenderer struct {
+	Options RenderOptions
+}
+
+// Render 实现 Renderer 接口
+func (r *GridBinaryRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	header := *NewGridFile(&Grid{
+		Rows: grid.Rows, Cols: grid.Cols,
+		CellWidth: grid.CellWidth, CellHeight: grid.CellHeight,
+		Width: grid.Width, Height: grid.Height,
+	}, r.Options)
+	header.Cells = nil
+	meta, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode grid: %v", err)
+	}
+
+	var b bytes.Buffer
+	b.Write(gridMagic)
+	b.Write(binary.AppendUvarint(nil, uint64(len(meta))))
+	b.Write(meta)
+	var scratch [binary.MaxVarintLen64]byte
+	for _, c := range grid.Cells {
+		b.Write(scratch[:binary.PutUvarint(scratch[:], uint64(c.Rune))])
+		b.Write([]byte{c.FG.R, c.FG.G, c.FG.B})
+		if c.BG.A != 0 {
+			b.Write([]byte{1, c.BG.R, c.BG.G, c.BG.B})
+		} else {
+			b.WriteByte(0)
+		}
+		binary.LittleEndian.PutUint32(scratch[:4], math.Float32bits(float32(c.Brightness)))
+		b.Write(scratch[:4])
+	}
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// ReadGridFile 读取 JSON 或二进制格式的导出数据，格式由文件头自动识别
+func ReadGridFile(r io.Reader) (*GridFile, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gridMagic))
+	if err == nil && bytes.Equal(magic, gridMagic) {
+		return readGridBinary(br)
+	}
+	var f GridFile
+	if err := json.NewDecoder(br).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode grid: %v", err)
+	}
+	return &f, nil
+}
+
+// readGridBinary 解码二进制格式，br 位于文件头处
+func readGridBinary(br *bufio.Reader) (*GridFile, error) {
+	fail := func(err error) (*GridFile, error) {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("failed to decode grid: %v", err)
+	}
+	if _, err := br.Discard(len(gridMagic)); err != nil {
+		return fail(err)
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fail(err)
+	}
+	if size > 1<<24 {
+		return fail(fmt.Errorf("metadata too large: %d bytes", size))
+	}
+	meta := make([]byte, size)
+	if _, err := io.ReadFull(br, meta); err != nil {
+		return fail(err)
+	}
+	var f GridFile
+	if err := json.Unmarshal(meta, &f); err != nil {
+		return fail(err)
+	}
+	if err := checkGridSize(f.Rows, f.Cols); err != nil {
+		return fail(err)
+	}
+
+	// 单元格边读边追加，头部声明的尺寸再大也只按实际读到的数据分配内存
+	n := f.Rows * f.Cols
+	f.Cells = make([]GridCell, 0, minInt(n, gridCellPrealloc))
+	var buf [4]byte
+	for i := 0; i < n; i++ {
+		code, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fail(err)
+		}
+		if _, err := io.ReadFull(br, buf[:4]); err != nil {
+			return fail(err)
+		}
+		cell := GridCell{Rune: string(rune(code)), FG: hexColor(color.RGBA{buf[0], buf[1], buf[2], 255})}
+		if buf[3] > 1 {
+			return fail(fmt.Errorf("cell %d has invalid background flag %d", i, buf[3]))
+		}
+		if buf[3] == 1 {
+			if _, err := io.ReadFull(br, buf[:3]); err != nil {
+				return fail(err)
+			}
+			cell.BG = hexColor(color.RGBA{buf[0], buf[1], buf[2], 255})
+		}
+		if _, err := io.ReadFull(br, buf[:4]); err != nil {
+			return fail(err)
+		}
+		cell.Brightness = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])))
+		f.Cells = append(f.Cells, cell)
+	}
+	return &f, nil
+}
+
+// checkGridSize 检查行数和列数为正且不超过上限；先分别检查再相乘，乘积不会溢出
+func checkGridSize(rows, cols int) error {
+	if rows <= 0 || cols <= 0 || rows > maxGridSide || cols > maxGridSide || rows*cols > maxGridCells {
+		return fmt.Errorf("invalid dimensions %dx%d", cols, rows)
+	}
+	return nil
+}
+
+// RenderGridFile 读取 cfg.InputPath 中导出的字符网格，按输出格式重新渲染到 cfg.OutputPath。
+// 未指定 FontPath 时使用导出时的字体。
+func RenderGridFile(cfg *config.Config) error {
+	return RenderGridFileContext(context.Background(), cfg)
+}
+
+// RenderGridFileContext 与 RenderGridFile 相同，ctx 取消时停止渲染，不会留下不完整的输出文件
+func RenderGridFileContext(ctx context.Context, cfg *config.Config) error {
+	format, err := resolveFormat(cfg.Format, cfg.OutputPath, textFallback(cfg))
+	if err != nil {
+		return err
+	}
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	tracker := progress.NewTracker(ctx, progress.StageDecode, 1)
+	input, err := os.Open(cfg.InputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %v", err)
+	}
+	file, err := ReadGridFile(input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+	grid, err := file.Grid()
+	if err != nil {
+		return err
+	}
+	tracker.Finish()
+
+	opts := p.RenderOptions(false)
+	if cfg.FontPath == "" && file.Font.Path != "" {
+		opts.Font = fonts.FontConfig{Path: file.Font.Path, Size: file.Font.Size}
+	}
+	opts.Charset = []rune(file.Charset)
+	if file.Settings != nil {
+		opts.Config = file.Settings.apply(opts.Config)
+	}
+	renderer, err := NewRenderer(format, opts)
+	if err != nil {
+		return err
+	}
+	return renderFile(progress.WithStage(ctx, progress.StageDraw), cfg.OutputPath, renderer, grid)
+}
+
+// hexColor 返回 #rrggbb 形式的颜色
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// parseHexColor 解析 #rrggbb 形式的颜色
+func parseHexColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	c.A = 255
+	return c, nil
+}
@@ -0,0 +1,208 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// exportTestGrid 返回包含背景色和多字节字符的网格
+func exportTestGrid() *Grid {
+	grid := NewGrid(2, 2)
+	grid.CellWidth, grid.CellHeight = 8, 16
+	grid.Width, grid.Height = 16, 32
+	grid.Cells[0] = Cell{Rune: '@', FG: color.RGBA{255, 0, 0, 255}, Brightness: 0.25}
+	grid.Cells[1] = Cell{Rune: '▚', FG: color.RGBA{0, 255, 0, 255}, BG: color.RGBA{0, 0, 255, 255}, Brightness: 0.5}
+	grid.Cells[2] = Cell{Rune: '"', FG: color.RGBA{1, 2, 3, 255}, Brightness: 1}
+	grid.Cells[3] = Cell{Rune: ' ', FG: color.RGBA{0, 0, 0, 255}}
+	return grid
+}
+
+// 测试 JSON 和二进制格式导出后能读回相同的网格和元数据
+func TestGridExportRoundTrip(t *testing.T) {
+	opts := RenderOptions{
+		Font:    fonts.FontConfig{Path: "fonts/test.ttf", Size: 20},
+		Charset: []rune("@%#*+=-:. "),
+		Config:  &config.Config{InputPath: "/secret/input.jpg", OutputPath: "/secret/output.json", NumCols: 2, GlyphMode: "quadrant", Tone: config.ToneConfig{Gamma: 2.2}},
+	}
+	for _, r := range []Renderer{&GridJSONRenderer{Options: opts}, &GridBinaryRenderer{Options: opts}} {
+		var buf bytes.Buffer
+		if err := r.Render(context.Background(), &buf, exportTestGrid()); err != nil {
+			t.Fatalf("%T: Render failed: %v", r, err)
+		}
+		file, err := ReadGridFile(&buf)
+		if err != nil {
+			t.Fatalf("%T: ReadGridFile failed: %v", r, err)
+		}
+		if file.Version != GridSchemaVersion || file.Charset != "@%#*+=-:. " || file.Font.Path != "fonts/test.ttf" || file.Settings.GlyphMode != "quadrant" || file.Settings.Tone.Gamma != 2.2 {
+			t.Errorf("%T: unexpected metadata %+v", r, file)
+		}
+		grid, err := file.Grid()
+		if err != nil {
+			t.Fatalf("%T: Grid failed: %v", r, err)
+		}
+		if !reflect.DeepEqual(grid, exportTestGrid()) {
+			t.Errorf("%T: expected %+v, got %+v", r, exportTestGrid(), grid)
+		}
+	}
+}
+
+// 测试 JSON 格式的字段名和字符转义，设置中不包含文件路径
+func TestGridJSONFields(t *testing.T) {
+	opts := RenderOptions{Config: &config.Config{InputPath: "/secret/input.jpg", OutputPath: "/secret/output.json", GlyphMode: "braille", Tone: config.ToneConfig{Invert: "true"}}}
+	var buf bytes.Buffer
+	if err := (&GridJSONRenderer{Options: opts}).Render(context.Background(), &buf, exportTestGrid()); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{`"version":1`, `"rows":2`, `"cell_width":8`, `{"rune":"▚","fg":"#00ff00","bg":"#0000ff","brightness":0.5}`, `{"rune":"\"","fg":"#010203","brightness":1}`, `"glyph_mode":"braille"`, `"invert":"true"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in %s", want, buf.String())
+		}
+	}
+	for _, unwanted := range []string{"/secret", "InputPath", "GlyphMode"} {
+		if strings.Contains(buf.String(), unwanted) {
+			t.Errorf("expected no %s in %s", unwanted, buf.String())
+		}
+	}
+}
+
+// 测试无效的导出数据返回错误
+func TestReadGridFileInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&GridBinaryRenderer{}).Render(context.Background(), &buf, exportTestGrid()); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, err := ReadGridFile(bytes.NewReader(truncated)); err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("expected unexpected EOF for truncated grid, got %v", err)
+	}
+
+	tests := map[string]string{
+		"version": `{"version":2,"rows":0,"cols":0,"cells":[]}`,
+		"cells":   `{"version":1,"rows":1,"cols":2,"cells":[]}`,
+		"color":   `{"version":1,"rows":1,"cols":1,"cells":[{"rune":"a","fg":"red"}]}`,
+		"rune":    `{"version":1,"rows":1,"cols":1,"cells":[{"rune":"ab","fg":"#000000"}]}`,
+		"empty":   `{"version":1,"rows":0,"cols":0,"cells":[]}`,
+		// 1<<32 乘 1<<32 在 64 位整数上溢出为 0，与空的单元格列表长度相同
+		"overflow": `{"version":1,"rows":4294967296,"cols":4294967296,"cells":[]}`,
+		"negative": `{"version":1,"rows":-1,"cols":-1,"cells":[{"rune":"a","fg":"#000000"}]}`,
+	}
+	for name, data := range tests {
+		file, err := ReadGridFile(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: ReadGridFile failed: %v", name, err)
+		}
+		if _, err := file.Grid(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// 测试二进制格式中恶意的网格尺寸在分配单元格之前被拒绝
+func TestReadGridBinaryHostileHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows, cols int
+	}{
+		{"zero rows", 0, 10},
+		{"zero cols", 10, 0},
+		{"negative", -2, -3},
+		{"overflow", 1 << 32, 1 << 32},
+		{"wraps negative", 1 << 62, 4},
+		{"side too large", 1, maxGridSide + 1},
+		{"too many cells", maxGridSide, maxGridSide},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := fmt.Sprintf(`{"version":1,"rows":%d,"cols":%d}`, tt.rows, tt.cols)
+			data := append(append([]byte("ASCG"), binary.AppendUvarint(nil, uint64(len(meta)))...), meta...)
+			_, err := ReadGridFile(bytes.NewReader(data))
+			if err == nil || !strings.Contains(err.Error(), "invalid dimensions") {
+				t.Errorf("expected invalid dimensions error, got %v", err)
+			}
+		})
+	}
+}
+
+// 测试声明了最大尺寸却没有单元格数据的文件不会按声明的尺寸分配内存，无效的背景标志返回错误
+func TestReadGridBinaryTruncatedAllocation(t *testing.T) {
+	header := func(rows, cols int) []byte {
+		meta := fmt.Sprintf(`{"version":1,"rows":%d,"cols":%d}`, rows, cols)
+		return append(append([]byte("ASCG"), binary.AppendUvarint(nil, uint64(len(meta)))...), meta...)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ReadGridFile(bytes.NewReader(header(1<<13, 1<<13)))
+	runtime.ReadMemStats(&after)
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("expected unexpected EOF, got %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("expected a small allocation for a truncated file, got %d bytes", allocated)
+	}
+
+	// 字符 'a'、前景色和值为 2 的背景标志
+	data := append(header(1, 1), 'a', 1, 2, 3, 2)
+	if _, err := ReadGridFile(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "background flag") {
+		t.Errorf("expected invalid background flag error, got %v", err)
+	}
+}
+
+// 测试导出的网格经 render 模式重新渲染后与直接转换的结果相同
+func TestRenderGridFile(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+
+	cfg := MockConfig(inputPath, filepath.Join(dir, "direct.txt"), 10, 1, "simple", "black")
+	if err := ImageToText(cfg); err != nil {
+		t.Fatalf("ImageToText failed: %v", err)
+	}
+	cfg.OutputPath = filepath.Join(dir, "export.grid")
+	if err := ImageToText(cfg); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	render := MockConfig(cfg.OutputPath, filepath.Join(dir, "rendered.txt"), 10, 1, "simple", "black")
+	if err := RenderGridFile(render); err != nil {
+		t.Fatalf("RenderGridFile failed: %v", err)
+	}
+	direct, _ := os.ReadFile(filepath.Join(dir, "direct.txt"))
+	rendered, _ := os.ReadFile(render.OutputPath)
+	if len(direct) == 0 || !bytes.Equal(direct, rendered) {
+		t.Errorf("expected %q, got %q", direct, rendered)
+	}
+
+	// 重新导出时保留生成网格时的设置
+	render.OutputPath = filepath.Join(dir, "reexport.json")
+	render.GlyphMode = "edge"
+	if err := RenderGridFile(render); err != nil {
+		t.Fatalf("RenderGridFile failed: %v", err)
+	}
+	reexport, err := os.Open(render.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open re-export: %v", err)
+	}
+	defer reexport.Close()
+	file, err := ReadGridFile(reexport)
+	if err != nil {
+		t.Fatalf("ReadGridFile failed: %v", err)
+	}
+	if file.Settings == nil || file.Settings.GlyphMode != cfg.GlyphMode || file.Settings.Cols != 10 {
+		t.Errorf("expected settings of the original export, got %+v", file.Settings)
+	}
+}
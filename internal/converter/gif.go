@@ -0,0 +1,219 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// gifMagic GIF87a 和 GIF89a 文件头的公共前缀
+var gifMagic = []byte("GIF8")
+
+// GIFAnimation 解码后的动画 GIF，每帧都是按处置方法合成后的完整画面
+type GIFAnimation struct {
+	Frames []*image.RGBA
+	// Delays 每帧的显示时长，单位为 1/100 秒
+	Delays []int
+}
+
+// DecodeGIF 解码 r 中的动画 GIF，按每帧的处置方法合成画面。
+// 与浏览器相同，不超过 1/100 秒的延迟按 1/10 秒处理。
+func DecodeGIF(r io.Reader) (*GIFAnimation, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gif: %v", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("failed to decode gif: no frames")
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	anim := &GIFAnimation{}
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		delay := g.Delay[i]
+		if delay <= 1 {
+			delay = 10
+		}
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+		anim.Delays = append(anim.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return anim, nil
+}
+
+// Sample 以每秒 fps 帧对动画重新采样，返回每个采样时刻显示的帧
+func (a *GIFAnimation) Sample(fps int) []image.Image {
+	total := 0
+	for _, delay := range a.Delays {
+		total += delay
+	}
+	n := int(math.Ceil(float64(total) * float64(fps) / 100))
+	if n < 1 {
+		n = 1
+	}
+
+	frames := make([]image.Image, 0, n)
+	i, end := 0, a.Delays[0]
+	for k := 0; k < n; k++ {
+		t := float64(k) * 100 / float64(fps)
+		for i < len(a.Frames)-1 && t >= float64(end) {
+			i++
+			end += a.Delays[i]
+		}
+		frames = append(frames, a.Frames[i])
+	}
+	return frames
+}
+
+// GIFEncoder 将图像帧编码为无限循环的动画 GIF，不依赖 ffmpeg。
+// 每帧使用各自的调色板，颜色超过 256 种时用中位切分法选取；帧在 Close 时一次写出。
+type GIFEncoder struct {
+	w    io.Writer
+	fps  int
+	anim gif.GIF
+}
+
+// NewGIFEncoder 创建以每秒 fps 帧编码动画并写入 w 的编码器
+func NewGIFEncoder(w io.Writer, fps int) *GIFEncoder {
+	return &GIFEncoder{w: w, fps: fps}
+}
+
+// WriteFrame 按编码器的帧率写入一帧，所有帧的尺寸必须与第一帧相同
+func (e *GIFEncoder) WriteFrame(img image.Image) error {
+	// 按累计时间计算延迟，帧率不能整除 100 时总时长仍然准确
+	n := len(e.anim.Image)
+	delay := int(math.Round(100*float64(n+1)/float64(e.fps)) - math.Round(100*float64(n)/float64(e.fps)))
+	return e.WriteFrameDelay(img, delay)
+}
+
+// WriteFrameDelay 写入显示 delay 个 1/100 秒的一帧，用于保留输入动画的帧延迟
+func (e *GIFEncoder) WriteFrameDelay(img image.Image, delay int) error {
+	if len(e.anim.Image) > 0 && img.Bounds().Size() != e.anim.Image[0].Rect.Size() {
+		return fmt.Errorf("frame size %v differs from first frame %v", img.Bounds().Size(), e.anim.Image[0].Rect.Size())
+	}
+	if delay < 1 {
+		delay = 1
+	}
+	e.anim.Image = append(e.anim.Image, quantize(img))
+	e.anim.Delay = append(e.anim.Delay, delay)
+	return nil
+}
+
+// Close 写出动画；没有写入任何帧时返回错误
+func (e *GIFEncoder) Close() error {
+	if len(e.anim.Image) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	if err := gif.EncodeAll(e.w, &e.anim); err != nil {
+		return fmt.Errorf("failed to encode gif: %v", err)
+	}
+	return nil
+}
+
+// Abort 丢弃已写入的帧，不写出动画
+func (e *GIFEncoder) Abort() {
+	e.anim = gif.GIF{}
+}
+
+// quantize 将图像转换为调色板图像，颜色不超过 256 种时保持不变
+func quantize(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	}
+	counts := map[color.RGBA]int{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := rgba.RGBAAt(x, y)
+			c.A = 255
+			counts[c]++
+		}
+	}
+	var colors []color.RGBA
+	if len(counts) <= 256 {
+		for c := range counts {
+			colors = append(colors, c)
+		}
+		// 固定调色板顺序，相同的输入得到相同的输出
+		sort.Slice(colors, func(i, j int) bool {
+			a, b := colors[i], colors[j]
+			return int(a.R)<<16|int(a.G)<<8|int(a.B) < int(b.R)<<16|int(b.G)<<8|int(b.B)
+		})
+	} else {
+		colors = medianCut(counts, 256)
+	}
+	palette := make(color.Palette, len(colors))
+	for i, c := range colors {
+		palette[i] = c
+	}
+
+	out := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), palette)
+	index := make(map[color.RGBA]uint8, len(counts))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := rgba.RGBAAt(x, y)
+			c.A = 255
+			i, ok := index[c]
+			if !ok {
+				i = uint8(palette.Index(c))
+				index[c] = i
+			}
+			out.SetColorIndex(x-bounds.Min.X, y-bounds.Min.Y, i)
+		}
+	}
+	return out
+}
+
+// decodeGIFFile 解码 path 中的动画 GIF；文件不是 GIF 时返回 nil
+func decodeGIFFile(path string) (*GIFAnimation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %v", err)
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	if !isGIF(br) {
+		return nil, nil
+	}
+	return DecodeGIF(br)
+}
+
+// isGIF 按文件头判断 br 中的数据是否为 GIF，不消耗数据
+func isGIF(br *bufio.Reader) bool {
+	magic, err := br.Peek(len(gifMagic))
+	return err == nil && bytes.Equal(magic, gifMagic)
+}
+
+// cloneRGBA 返回图像的副本
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Rect)
+	copy(out.Pix, img.Pix)
+	return out
+}
@@ -0,0 +1,204 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	gifRed   = color.RGBA{255, 0, 0, 255}
+	gifGreen = color.RGBA{0, 255, 0, 255}
+	gifBlue  = color.RGBA{0, 0, 255, 255}
+)
+
+// testGIF 编码 4x4 的动画：全红的第一帧，随后是左上角 2x2 的绿色帧和右下角 2x2 的蓝色帧
+func testGIF(t *testing.T, disposal byte, delays ...int) []byte {
+	palette := color.Palette{color.Transparent, gifRed, gifGreen, gifBlue}
+	fill := func(rect image.Rectangle, index uint8) *image.Paletted {
+		img := image.NewPaletted(rect, palette)
+		for i := range img.Pix {
+			img.Pix[i] = index
+		}
+		return img
+	}
+	anim := &gif.GIF{
+		Image:    []*image.Paletted{fill(image.Rect(0, 0, 4, 4), 1), fill(image.Rect(0, 0, 2, 2), 2), fill(image.Rect(2, 2, 4, 4), 3)},
+		Delay:    delays,
+		Disposal: []byte{gif.DisposalNone, disposal, gif.DisposalNone},
+		Config:   image.Config{ColorModel: palette, Width: 4, Height: 4},
+	}
+	var b bytes.Buffer
+	if err := gif.EncodeAll(&b, anim); err != nil {
+		t.Fatalf("failed to encode test gif: %v", err)
+	}
+	return b.Bytes()
+}
+
+// 测试按处置方法合成每一帧
+func TestDecodeGIFDisposal(t *testing.T) {
+	tests := []struct {
+		name     string
+		disposal byte
+		// topLeft 第三帧左上角的颜色
+		topLeft color.RGBA
+	}{
+		{"none", gif.DisposalNone, gifGreen},
+		{"background", gif.DisposalBackground, color.RGBA{}},
+		{"previous", gif.DisposalPrevious, gifRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anim, err := DecodeGIF(bytes.NewReader(testGIF(t, tt.disposal, 5, 5, 5)))
+			if err != nil {
+				t.Fatalf("DecodeGIF failed: %v", err)
+			}
+			if len(anim.Frames) != 3 {
+				t.Fatalf("expected 3 frames, got %d", len(anim.Frames))
+			}
+			if c := anim.Frames[1].RGBAAt(0, 0); c != gifGreen {
+				t.Errorf("frame 1: expected green top left, got %v", c)
+			}
+			if c := anim.Frames[1].RGBAAt(3, 3); c != gifRed {
+				t.Errorf("frame 1: expected red kept outside the frame, got %v", c)
+			}
+			if c := anim.Frames[2].RGBAAt(0, 0); c != tt.topLeft {
+				t.Errorf("frame 2: expected %v top left, got %v", tt.topLeft, c)
+			}
+			if c := anim.Frames[2].RGBAAt(3, 3); c != gifBlue {
+				t.Errorf("frame 2: expected blue bottom right, got %v", c)
+			}
+		})
+	}
+
+	if _, err := DecodeGIF(strings.NewReader("GIF89a")); err == nil {
+		t.Error("expected error for truncated gif")
+	}
+}
+
+// 测试按帧延迟重新采样，过短的延迟按 1/10 秒处理
+func TestGIFAnimationSample(t *testing.T) {
+	anim, err := DecodeGIF(bytes.NewReader(testGIF(t, gif.DisposalNone, 20, 0, 10)))
+	if err != nil {
+		t.Fatalf("DecodeGIF failed: %v", err)
+	}
+	if anim.Delays[1] != 10 {
+		t.Errorf("expected zero delay to become 10, got %d", anim.Delays[1])
+	}
+	frames := anim.Sample(10)
+	want := []int{0, 0, 1, 2}
+	if len(frames) != len(want) {
+		t.Fatalf("expected %d samples, got %d", len(want), len(frames))
+	}
+	for i, index := range want {
+		if frames[i] != image.Image(anim.Frames[index]) {
+			t.Errorf("sample %d: expected frame %d", i, index)
+		}
+	}
+}
+
+// 测试动画 GIF 输入和输出不依赖 ffmpeg：GIF 转 GIF 保留帧延迟，转文本按帧率采样
+func TestVideoGIFWithoutFFmpeg(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.gif")
+	if err := os.WriteFile(inputPath, testGIF(t, gif.DisposalNone, 20, 10, 10), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	cfg := MockConfig(inputPath, filepath.Join(dir, "output.gif"), 2, 1, "simple", "black")
+	if err := VideoToVideoColor(cfg); err != nil {
+		t.Fatalf("VideoToVideoColor failed: %v", err)
+	}
+	file, err := os.Open(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 20 || anim.Delay[1] != 10 {
+		t.Errorf("expected 3 frames with delays [20 10 10], got %d with %v", len(anim.Image), anim.Delay)
+	}
+
+	cfg = MockConfig(inputPath, filepath.Join(dir, "output.txt"), 2, 1, "simple", "black")
+	if err := VideoToText(cfg); err != nil {
+		t.Fatalf("VideoToText failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if n := len(SplitTextFrames(data)); n != 4 {
+		t.Errorf("expected 4 frames at 10 fps, got %d", n)
+	}
+
+	// 图像模式输出文本时同样转换所有帧
+	os.Remove(cfg.OutputPath)
+	if err := ImageToText(cfg); err != nil {
+		t.Fatalf("ImageToText failed: %v", err)
+	}
+	image2text, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !bytes.Equal(image2text, data) {
+		t.Errorf("expected image2text to match video2text, got %q", image2text)
+	}
+}
+
+// 测试输出路径为 "-" 时 video2text 写入标准输出，视频输出返回错误且不会创建名为 "-" 的文件
+func TestVideoStdoutOutput(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.gif")
+	if err := os.WriteFile(inputPath, testGIF(t, gif.DisposalNone, 10, 10, 10), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout.txt"))
+	if err != nil {
+		t.Fatalf("failed to create stdout file: %v", err)
+	}
+	defer stdout.Close()
+	saved := os.Stdout
+	os.Stdout = stdout
+	cfg := MockConfig(inputPath, stdoutPath, 2, 1, "simple", "black")
+	err = VideoToText(cfg)
+	os.Stdout = saved
+	if err != nil {
+		t.Fatalf("VideoToText failed: %v", err)
+	}
+	data, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	if n := len(SplitTextFrames(data)); n != 3 {
+		t.Errorf("expected 3 frames on stdout, got %d", n)
+	}
+
+	if err := VideoToVideoColor(cfg); err == nil {
+		t.Error("expected error for video2video to stdout")
+	}
+	cfg.InputPath = stdout.Name()
+	if err := TextToVideo(cfg); err == nil {
+		t.Error("expected error for text2video to stdout")
+	}
+	if _, err := os.Stat(filepath.Join(dir, stdoutPath)); !os.IsNotExist(err) {
+		t.Errorf("expected no file named %q, got %v", stdoutPath, err)
+	}
+}
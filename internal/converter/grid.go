@@ -0,0 +1,96 @@
+package converter
+
+import (
+	"context"
+	"image/color"
+
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// Cell 字符网格中的一个单元格
+// BG 的 alpha 为 0 表示没有背景色，此时渲染器使用整体背景。
+type Cell struct {
+	Rune       rune
+	FG         color.RGBA
+	BG         color.RGBA
+	Brightness float64
+}
+
+// Grid 分析图像得到的字符网格，按行排列 Rows x Cols 个单元格。
+// 渲染器只依赖网格，不再访问源图像；CellWidth、CellHeight 为单元格在源图像中的像素尺寸，
+// Width、Height 为源图像尺寸，图像渲染器据此输出与源图像同尺寸的图像。
+type Grid struct {
+	Rows, Cols            int
+	Cells                 []Cell
+	CellWidth, CellHeight float64
+	Width, Height         int
+}
+
+// NewGrid 创建 rows x cols 的空网格
+func NewGrid(rows, cols int) *Grid {
+	return &Grid{Rows: rows, Cols: cols, Cells: make([]Cell, rows*cols)}
+}
+
+// At 返回第 row 行第 col 列的单元格
+func (g *Grid) At(row, col int) *Cell {
+	return &g.Cells[row*g.Cols+col]
+}
+
+// Row 返回第 row 行的单元格
+func (g *Grid) Row(row int) []Cell {
+	return g.Cells[row*g.Cols : (row+1)*g.Cols]
+}
+
+// HasBackground 判断是否有单元格指定了背景色，例如块元素模式
+func (g *Grid) HasBackground() bool {
+	for _, c := range g.Cells {
+		if c.BG.A != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// analyze 为源图像的每个单元格选择字符并计算颜色和亮度，生成字符网格。
+// 选择器决定单元格的颜色：cellPainter 提供前景色和背景色，cellColorer 提供前景色，
+// 否则前景色为单元格的平均颜色。
+func analyze(ctx context.Context, src *sampler, selector glyphSelector, layout cellLayout) (*Grid, error) {
+	tracker := progress.NewTracker(ctx, progress.StageSelect, 1)
+	runes := selector.selectRunes(src, layout)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bounds := src.bounds()
+	grid := NewGrid(layout.numRows, layout.numCols)
+	grid.CellWidth, grid.CellHeight = layout.cellWidth, layout.cellHeight
+	grid.Width, grid.Height = bounds.Max.X, bounds.Max.Y
+
+	painter, isPainter := selector.(cellPainter)
+	err := src.pool.RowsContext(ctx, layout.numRows, func(i int) {
+		for j, r := range runes[i] {
+			x, y, width, height := layout.cell(i, j)
+			cell := grid.At(i, j)
+			cell.Rune = r
+			cell.Brightness = src.brightness(x, y, width, height)
+			if isPainter {
+				fg, bg := painter.cellColors(src, x, y, width, height, r)
+				cell.FG, cell.BG = toRGBA(fg), toRGBA(bg)
+				continue
+			}
+			cell.FG = toRGBA(foregroundColor(selector, src, x, y, width, height, r))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracker.Finish()
+	return grid, nil
+}
+
+// toRGBA 将颜色转换为不透明的 color.RGBA
+func toRGBA(c color.Color) color.RGBA {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	rgba.A = 255
+	return rgba
+}
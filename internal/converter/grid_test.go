@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"context"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 测试分析得到的网格包含字符、颜色和亮度
+func TestAnalyze(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	img := generateTestImage(40, 40, red)
+	src := newSampler(img, srgbSpace, nil)
+	layout := newCellLayout(img.Bounds(), 4)
+
+	selector := &brightnessSelector{chars: []rune("@. "), tone: &toneMap{}, quant: &quantizer{}}
+	grid, err := analyze(context.Background(), src, selector, layout)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if grid.Rows != 2 || grid.Cols != 4 || len(grid.Cells) != 8 {
+		t.Fatalf("expected 2x4 grid, got %dx%d", grid.Rows, grid.Cols)
+	}
+	if grid.Width != 40 || grid.Height != 40 || grid.CellWidth != 10 || grid.CellHeight != 20 {
+		t.Errorf("unexpected grid geometry: %+v", grid)
+	}
+	for _, c := range grid.Cells {
+		if c.FG != red || c.BG.A != 0 {
+			t.Errorf("expected red foreground without background, got %v / %v", c.FG, c.BG)
+		}
+		if c.Brightness < 0.29 || c.Brightness > 0.31 {
+			t.Errorf("expected brightness around 0.299, got %f", c.Brightness)
+		}
+	}
+	if grid.HasBackground() {
+		t.Error("expected grid without background colors")
+	}
+
+	// 块元素模式的单元格带有背景色
+	grid, err = analyze(context.Background(), src, &blockSelector{shape: halfBlockShape}, layout)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if !grid.HasBackground() || grid.At(1, 3).BG != red {
+		t.Errorf("expected red background for block cells, got %v", grid.At(1, 3).BG)
+	}
+}
+
+// 测试文本渲染器在有背景色时输出 ANSI 文本，否则输出纯文本
+func TestTextRenderer(t *testing.T) {
+	grid := NewGrid(2, 2)
+	for i, r := range "ab.c" {
+		grid.Cells[i].Rune = r
+	}
+
+	var plain strings.Builder
+	if err := (&TextRenderer{}).Render(context.Background(), &plain, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if plain.String() != "ab\n.c\n" {
+		t.Errorf("expected plain text, got %q", plain.String())
+	}
+
+	grid.At(0, 0).BG = color.RGBA{0, 0, 255, 255}
+	var ansi strings.Builder
+	if err := (&TextRenderer{}).Render(context.Background(), &ansi, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.HasPrefix(ansi.String(), "\x1b[38;2;0;0;0m\x1b[48;2;0;0;255ma") {
+		t.Errorf("expected ANSI text, got %q", ansi.String())
+	}
+}
+
+// 测试块元素字符按形状填充，其他带背景色的字符先填充背景
+func TestDrawRowBackground(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	grid := NewGrid(1, 2)
+	grid.CellWidth, grid.CellHeight = 8, 16
+	grid.Width, grid.Height = 16, 16
+	*grid.At(0, 0) = Cell{Rune: '▀', FG: red, BG: blue}
+	*grid.At(0, 1) = Cell{Rune: ' ', FG: red, BG: blue}
+
+	cs, err := resolveCharset(&config.Config{Language: "english", CharMode: "simple"})
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	img, err := drawGrid(context.Background(), nil, grid, drawOptions{font: cs.font, background: color.Black})
+	if err != nil {
+		t.Fatalf("drawGrid failed: %v", err)
+	}
+	checks := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{4, 4, red},
+		{4, 12, blue},
+		{12, 4, blue},
+		{12, 12, blue},
+	}
+	for _, check := range checks {
+		if got := color.RGBAModel.Convert(img.At(check.x, check.y)); got != check.expected {
+			t.Errorf("pixel (%d, %d): expected %v, got %v", check.x, check.y, check.expected, got)
+		}
+	}
+}
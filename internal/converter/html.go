@@ -0,0 +1,195 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// DefaultHTMLFont HTML 输出默认使用的等宽字体栈
+const DefaultHTMLFont = `"DejaVu Sans Mono", Menlo, Consolas, "Liberation Mono", monospace`
+
+// HTMLOptions HTML 输出的参数
+type HTMLOptions struct {
+	// Fragment 为 true 时只输出 <pre> 元素，否则输出完整页面
+	Fragment bool
+	// Font CSS font-family 字体栈，为空时使用 DefaultHTMLFont
+	Font string
+	// Theme 为 true 时背景色、文字颜色和字体通过 CSS 变量 --ascii-bg、--ascii-fg 和 --ascii-font 设置，
+	// 嵌入页面可以覆盖这些变量
+	Theme bool
+}
+
+// HTMLRenderer 将字符网格输出为 HTML，字符放在 <pre> 中，颜色相同的连续字符合并为一个 <span>
+type HTMLRenderer struct {
+	Options    HTMLOptions
+	Background color.Color
+	// Mono 不为 nil 时所有字符使用该颜色，不输出 <span>
+	Mono color.Color
+	// Pool 用于并行生成标记，为 nil 时顺序执行
+	Pool *WorkerPool
+}
+
+// Render 实现 Renderer 接口
+// 每行结束时关闭 <span>，各行互不依赖，因此按行分块并行生成后按顺序拼接。
+func (r *HTMLRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	chunks, err := r.Pool.MapContext(ctx, grid.Rows, r.Pool.rowChunk(grid.Rows), func(start, end int) interface{} {
+		var chunk strings.Builder
+		for i := start; i < end; i++ {
+			r.writeRow(&chunk, grid.Row(i))
+		}
+		return chunk.String()
+	})
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if !r.Options.Fragment {
+		b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+		b.WriteString("<title>ASCII art</title>\n</head>\n")
+		fmt.Fprintf(&b, "<body style=\"margin: 0; background: %s;\">\n", r.cssVar("--ascii-bg", r.background()))
+	}
+	fmt.Fprintf(&b, "<pre class=\"ascii-art\" style=\"%s\">", r.preStyle(grid))
+	for _, chunk := range chunks {
+		b.WriteString(chunk.(string))
+	}
+	b.WriteString("</pre>\n")
+	if !r.Options.Fragment {
+		b.WriteString("</body>\n</html>\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// preStyle 返回 <pre> 的样式。字号随视口宽度缩放使整行可见，
+// 行高按单元格宽高比设置，使字符网格保持源图像的比例。
+func (r *HTMLRenderer) preStyle(grid *Grid) string {
+	font := r.Options.Font
+	if font == "" {
+		font = DefaultHTMLFont
+	}
+	fg := "#ffffff"
+	if r.Mono != nil {
+		fg = cssColor(r.Mono)
+	} else if r.background() == "#ffffff" {
+		fg = "#000000"
+	}
+
+	// 等宽字体的字宽约为 0.6em
+	const advance = 0.6
+	lineHeight := advance
+	if grid.CellWidth > 0 {
+		lineHeight = advance * grid.CellHeight / grid.CellWidth
+	}
+	cols := grid.Cols
+	if cols < 1 {
+		cols = 1
+	}
+	return strings.Join([]string{
+		"margin: 0",
+		"padding: 0",
+		"background: " + r.cssVar("--ascii-bg", r.background()),
+		"color: " + r.cssVar("--ascii-fg", fg),
+		"font-family: " + r.cssVar("--ascii-font", htmlEscape(font)),
+		fmt.Sprintf("font-size: min(16px, calc(100vw / %.1f))", float64(cols)*advance),
+		fmt.Sprintf("line-height: %.3f", lineHeight),
+		"white-space: pre",
+	}, "; ") + ";"
+}
+
+// cssVar 启用主题时返回引用 CSS 变量、以 value 为默认值的表达式，否则直接返回 value
+func (r *HTMLRenderer) cssVar(name, value string) string {
+	if !r.Options.Theme {
+		return value
+	}
+	return fmt.Sprintf("var(%s, %s)", name, value)
+}
+
+// background 返回背景色的 CSS 表示
+func (r *HTMLRenderer) background() string {
+	if r.Background == nil {
+		return "#000000"
+	}
+	return cssColor(r.Background)
+}
+
+// writeRow 输出一行字符，样式相同的连续字符合并为一个 <span>。
+// 没有背景色的空格不显示前景色，因此并入当前 <span>，当前 <span> 有背景色时放在 <span> 之外。
+func (r *HTMLRenderer) writeRow(b *strings.Builder, row []Cell) {
+	open := ""
+	for _, c := range row {
+		if r.Mono == nil {
+			style := open
+			if c.Rune != ' ' || c.BG.A != 0 {
+				style = "color: " + cssColor(c.FG)
+				if c.BG.A != 0 {
+					style += "; background: " + cssColor(c.BG)
+				}
+			} else if strings.Contains(open, "background") {
+				style = ""
+			}
+			if style != open {
+				if open != "" {
+					b.WriteString("</span>")
+				}
+				if style != "" {
+					fmt.Fprintf(b, "<span style=\"%s\">", style)
+				}
+				open = style
+			}
+		}
+		b.WriteString(htmlEscapeRune(c.Rune))
+	}
+	if open != "" {
+		b.WriteString("</span>")
+	}
+	b.WriteString("\n")
+}
+
+// cssColor 返回颜色的 CSS 表示，完全透明时为 transparent
+func cssColor(c color.Color) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if rgba.A == 0 {
+		return "transparent"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// htmlEscapeRune 转义 HTML 特殊字符。反斜杠和引号在部分字符集中出现，
+// 转义后嵌入属性值或模板时也不会被误解析。
+func htmlEscapeRune(r rune) string {
+	switch r {
+	case '&':
+		return "&amp;"
+	case '<':
+		return "&lt;"
+	case '>':
+		return "&gt;"
+	case '"':
+		return "&quot;"
+	case '\'':
+		return "&#39;"
+	case '\\':
+		return "&#92;"
+	case '`':
+		return "&#96;"
+	default:
+		return string(r)
+	}
+}
+
+// htmlEscape 转义字符串中的 HTML 特殊字符
+func htmlEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteString(htmlEscapeRune(r))
+	}
+	return b.String()
+}
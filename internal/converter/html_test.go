@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"context"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// 测试颜色相同的连续字符合并为一个 <span>，没有背景色的空格并入当前 <span>
+func TestHTMLRendererSpans(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	grid := NewGrid(2, 4)
+	for i := range grid.Cells {
+		grid.Cells[i] = Cell{Rune: '#', FG: red}
+	}
+	grid.Cells[1].Rune = ' '
+	grid.Cells[3].FG = blue
+	grid.Cells[5] = Cell{Rune: ' ', FG: red, BG: blue}
+
+	var b strings.Builder
+	r := &HTMLRenderer{Options: HTMLOptions{Fragment: true}}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.String()
+	if !strings.HasPrefix(out, `<pre class="ascii-art" style="`) || !strings.HasSuffix(out, "</pre>\n") {
+		t.Fatalf("expected a <pre> fragment, got %q", out)
+	}
+	body := out[strings.Index(out, ">")+1 : len(out)-len("</pre>\n")]
+	expected := `<span style="color: #ff0000"># #</span><span style="color: #0000ff">#</span>` + "\n" +
+		`<span style="color: #ff0000">#</span><span style="color: #ff0000; background: #0000ff"> </span><span style="color: #ff0000">##</span>` + "\n"
+	if body != expected {
+		t.Errorf("expected %q, got %q", expected, body)
+	}
+}
+
+// 测试特殊字符转义
+func TestHTMLRendererEscape(t *testing.T) {
+	grid := NewGrid(1, 7)
+	for i, r := range `<&>"'\` + "`" {
+		grid.Cells[i] = Cell{Rune: r}
+	}
+	var b strings.Builder
+	r := &HTMLRenderer{Options: HTMLOptions{Fragment: true}, Mono: color.White}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(b.String(), ">&lt;&amp;&gt;&quot;&#39;&#92;&#96;\n</pre>") {
+		t.Errorf("unexpected escaping in %q", b.String())
+	}
+	if strings.Contains(b.String(), "<span") {
+		t.Errorf("mono output should not contain spans: %q", b.String())
+	}
+}
+
+// 测试完整页面、背景色、字体栈和 CSS 变量主题
+func TestHTMLRendererPage(t *testing.T) {
+	grid := NewGrid(1, 1)
+	grid.Cells[0] = Cell{Rune: 'a', FG: color.RGBA{0, 0, 0, 255}}
+	grid.CellWidth, grid.CellHeight = 10, 20
+
+	var b strings.Builder
+	r := &HTMLRenderer{
+		Options:    HTMLOptions{Font: `"Fira Code", monospace`, Theme: true},
+		Background: color.White,
+	}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<meta charset="utf-8">`,
+		"background: var(--ascii-bg, #ffffff)",
+		"color: var(--ascii-fg, #000000)",
+		"font-family: var(--ascii-font, &quot;Fira Code&quot;, monospace)",
+		"line-height: 1.200",
+		"</html>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
@@ -119,9 +119,15 @@ func TestOutputFileCreation(t *testing.T) {
 
 // TestUnsupportedOutputFormat tests for unsupported output formats
 func TestUnsupportedOutputFormat(t *testing.T) {
-	cfg := MockConfig("input.jpg", "output.txt", 3, 1, "simple", "black")
+	cfg := MockConfig("input.jpg", "output.xyz", 3, 1, "simple", "black")
 
 	// Test ImageToImageColor function
 	err := ImageToImageColor(cfg)
-	assert.Error(t, err, "expected error for unsupported format")
+	assert.EqualError(t, err, "unsupported output format: .xyz")
+
+	// An explicit format that is not registered is rejected as well
+	cfg.OutputPath = "output.jpg"
+	cfg.Format = "xyz"
+	err = ImageToImageColor(cfg)
+	assert.EqualError(t, err, "unsupported output format: xyz")
 }
@@ -1,12 +1,19 @@
 package converter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/jpeg"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
 )
 
 // createTestImage 用于生成一个简单的测试图像并保存为JPEG格式
@@ -70,6 +77,39 @@ func TestImageToText(t *testing.T) {
 	os.Remove(outputPath)
 }
 
+// 测试默认设置下字符梯度的方向与基线一致：深色背景不反转，暗部使用最浓的字符；白色背景自动反转
+func TestImageToTextDefaultRamp(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+
+	for _, test := range []struct {
+		background   string
+		edge, center byte
+	}{
+		{"black", '.', '@'},
+		{"white", '@', '.'},
+	} {
+		cfg := MockConfig(inputPath, filepath.Join(dir, test.background+".txt"), 10, 1, "simple", test.background)
+		if err := ImageToText(cfg); err != nil {
+			t.Fatalf("ImageToText failed: %v", err)
+		}
+		data, err := os.ReadFile(cfg.OutputPath)
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		if lines[0] != strings.Repeat(string(test.edge), 10) {
+			t.Errorf("%s: expected first row of %q, got %q", test.background, test.edge, lines[0])
+		}
+		if c := lines[len(lines)/2][5]; c != test.center {
+			t.Errorf("%s: expected %q in the center, got %q", test.background, test.center, c)
+		}
+	}
+}
+
 // 测试 ImageToImage 函数
 func TestImageToImage(t *testing.T) {
 	// 创建一个临时图像文件作为输入
@@ -106,3 +146,117 @@ func TestImageToImage(t *testing.T) {
 	os.Remove(inputPath)
 	os.Remove(outputPath)
 }
+
+// 测试取消的转换返回 ctx 的错误且不留下输出文件
+func TestImageToTextContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	outputPath := filepath.Join(dir, "output.txt")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := MockConfig(inputPath, outputPath, 10, 1, "simple", "black")
+	if err := ImageToTextContext(ctx, cfg); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the input file, got %d entries", len(entries))
+	}
+}
+
+// 测试转换过程中按阶段发送进度事件，绘制阶段由工作池报告
+func TestImageToImageColorProgress(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	outputPath := filepath.Join(dir, "output.jpg")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []progress.Event
+	ctx := progress.WithReporter(context.Background(), progress.ReporterFunc(func(e progress.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+
+	cfg := MockConfig(inputPath, outputPath, 10, 1, "simple", "black")
+	if err := ImageToImageColorContext(ctx, cfg); err != nil {
+		t.Fatalf("ImageToImageColorContext failed: %v", err)
+	}
+
+	// 每个阶段都应以完成事件结束
+	last := map[string]progress.Event{}
+	var stages []string
+	for _, e := range events {
+		if _, ok := last[e.Stage]; !ok {
+			stages = append(stages, e.Stage)
+		}
+		last[e.Stage] = e
+	}
+	expected := []string{progress.StageDecode, progress.StageSelect, progress.StageDraw, progress.StageWrite}
+	if fmt.Sprint(stages) != fmt.Sprint(expected) {
+		t.Fatalf("expected stages %v, got %v", expected, stages)
+	}
+	for stage, e := range last {
+		if e.Done != e.Total || e.Total == 0 {
+			t.Errorf("stage %s did not complete: %+v", stage, e)
+		}
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("failed to stat output: %v", err)
+	}
+	if last[progress.StageWrite].Bytes != info.Size() {
+		t.Errorf("expected %d bytes written, got %d", info.Size(), last[progress.StageWrite].Bytes)
+	}
+}
+
+// 测试 PNG 输出的透明背景，以及不支持透明度的格式报错
+func TestImageToImageColorTransparent(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+
+	cfg := MockConfig(inputPath, filepath.Join(dir, "output.png"), 10, 1, "simple", "black")
+	cfg.Transparent = true
+	cfg.PNGCompression = "best"
+	if err := ImageToImageColor(cfg); err != nil {
+		t.Fatalf("ImageToImageColor failed: %v", err)
+	}
+	file, err := os.Open(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer file.Close()
+	img, format, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if format != "png" {
+		t.Fatalf("expected png output, got %s", format)
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("expected transparent margin, got alpha %d", a)
+	}
+
+	cfg.OutputPath = filepath.Join(dir, "output.jpg")
+	if err := ImageToImageColor(cfg); err == nil || !strings.Contains(err.Error(), "does not support transparent") {
+		t.Errorf("expected transparency error for jpeg, got %v", err)
+	}
+	if _, err := os.Stat(cfg.OutputPath); !os.IsNotExist(err) {
+		t.Errorf("expected no jpeg output, got %v", err)
+	}
+}
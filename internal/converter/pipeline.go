@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/encoder"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+	"github.com/hai119/Go-ASCII-generator/internal/utils"
+	_ "golang.org/x/image/webp"
+)
+
+// Pipeline 由配置生成的转换流程：分析图像得到字符网格，再交给渲染器输出。
+// 各转换模式只是解码输入、选择渲染器和写出结果的不同组合。
+type Pipeline struct {
+	cfg      *config.Config
+	cs       *charset
+	selector glyphSelector
+	space    colorSpace
+	ansi     ColorDepth
+	pool     *WorkerPool
+}
+
+// NewPipeline 根据配置创建转换流程并启动工作池，使用完毕后需要调用 Close
+func NewPipeline(cfg *config.Config) (*Pipeline, error) {
+	cs, err := resolveCharset(cfg)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := newGlyphSelector(cs, cfg)
+	if err != nil {
+		return nil, err
+	}
+	space, err := parseColorSpace(cfg.ColorSpace)
+	if err != nil {
+		return nil, err
+	}
+	encoding := encoder.Options{Quality: cfg.JPEGQuality, Compression: cfg.PNGCompression}
+	if err := encoding.Validate(); err != nil {
+		return nil, err
+	}
+	ansi, err := resolveColorDepth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseSVGFont(cfg.SVGFont); err != nil {
+		return nil, err
+	}
+
+	// 工作池用于采样、选择字符和绘制，视频的所有帧共享
+	pool := NewWorkerPool(cfg.Workers)
+	pool.Start()
+	return &Pipeline{cfg: cfg, cs: cs, selector: selector, space: space, ansi: ansi, pool: pool}, nil
+}
+
+// Close 停止工作池
+func (p *Pipeline) Close() {
+	p.pool.Stop()
+}
+
+// Analyze 将图像分析为字符网格
+func (p *Pipeline) Analyze(ctx context.Context, img image.Image) (*Grid, error) {
+	layout := newCellLayout(img.Bounds(), p.cfg.NumCols)
+	return analyze(ctx, newSampler(img, p.space, p.pool), p.selector, layout)
+}
+
+// RenderOptions 返回创建渲染器所需的参数：配置的字体、背景色和编码参数，mono 为 true 时使用与背景相反的单一颜色
+func (p *Pipeline) RenderOptions(mono bool) RenderOptions {
+	opts := RenderOptions{
+		Font:           p.cs.font,
+		Background:     getBgColor(p.cfg.Background),
+		Pool:           p.pool,
+		Encoding:       encoder.Options{Quality: p.cfg.JPEGQuality, Compression: p.cfg.PNGCompression},
+		Transparent:    p.cfg.Transparent,
+		Padding:        p.cfg.Padding,
+		ANSI:           p.ansi,
+		ANSIBackground: p.cfg.ANSIBackground,
+		HTML:           HTMLOptions{Fragment: p.cfg.HTMLFragment, Font: p.cfg.HTMLFont, Theme: p.cfg.HTMLTheme},
+		SVGFont:        p.cfg.SVGFont,
+		SAUCE:          p.sauce(),
+		Charset:        p.cs.chars,
+		Config:         p.cfg,
+	}
+	if opts.Transparent {
+		opts.Background = color.Transparent
+	}
+	if mono {
+		opts.Mono = color.White
+		if p.cfg.Background == "white" {
+			opts.Mono = color.Black
+		}
+	}
+	return opts
+}
+
+// sauce 返回配置中的 SAUCE 元数据，未指定标题时使用输入文件名
+func (p *Pipeline) sauce() SAUCE {
+	s := SAUCE{Title: p.cfg.SAUCETitle, Author: p.cfg.SAUCEAuthor, Group: p.cfg.SAUCEGroup, Font: p.cfg.SAUCEFont}
+	if s.Title == "" && p.cfg.InputPath != "" {
+		base := filepath.Base(p.cfg.InputPath)
+		s.Title = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return s
+}
+
+// TextRenderer 返回文本渲染器
+func (p *Pipeline) TextRenderer() *TextRenderer {
+	return &TextRenderer{Pool: p.pool}
+}
+
+// ImageRenderer 返回绘制到配置背景色上的图像渲染器，用于视频帧，因此忽略透明背景设置
+func (p *Pipeline) ImageRenderer(mono bool) *ImageRenderer {
+	opts := p.RenderOptions(mono)
+	return &ImageRenderer{Font: opts.Font, Background: getBgColor(p.cfg.Background), Mono: opts.Mono, Pool: opts.Pool}
+}
+
+// convertImage 解码 cfg.InputPath 的图像并分析为字符网格，渲染后写入 cfg.OutputPath。
+// 输出格式由 cfg.Format 指定，否则按输出文件的扩展名识别，都没有时使用 fallback。
+// 动画 GIF 输出为文本或 GIF 文件时按视频转换所有帧，其他格式只使用第一帧。
+func convertImage(ctx context.Context, cfg *config.Config, fallback string, mono bool) error {
+	format, err := resolveFormat(cfg.Format, cfg.OutputPath, fallback)
+	if err != nil {
+		return err
+	}
+	if cfg.OutputPath != stdoutPath && (format.Name == "text" || format.Name == "gif") {
+		anim, err := decodeGIFFile(cfg.InputPath)
+		if err != nil {
+			return err
+		}
+		if anim != nil && len(anim.Frames) > 1 {
+			if format.Name == "text" {
+				return VideoToTextContext(ctx, cfg)
+			}
+			return videoToVideo(ctx, cfg, mono)
+		}
+	}
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	tracker := progress.NewTracker(ctx, progress.StageDecode, 1)
+	img, err := decodeImageFile(cfg.InputPath)
+	if err != nil {
+		return err
+	}
+	tracker.Finish()
+
+	renderer, err := NewRenderer(format, p.RenderOptions(mono))
+	if err != nil {
+		return err
+	}
+	grid, err := p.Analyze(ctx, img)
+	if err != nil {
+		return err
+	}
+	return renderFile(progress.WithStage(ctx, progress.StageDraw), cfg.OutputPath, renderer, grid)
+}
+
+// decodeImageFile 打开并解码图像文件
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	return img, nil
+}
+
+// renderFile 将字符网格渲染到 path，写入同目录下的临时文件，完成后才替换目标文件。
+// path 为 "-" 时直接写入标准输出。
+func renderFile(ctx context.Context, path string, renderer Renderer, grid *Grid) error {
+	if path == stdoutPath {
+		return renderTracked(ctx, os.Stdout, renderer, grid)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	output, err := utils.CreateAtomic(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer output.Close()
+
+	if err := renderTracked(ctx, output, renderer, grid); err != nil {
+		return err
+	}
+	return output.Commit()
+}
+
+// renderTracked 将字符网格渲染到 w。
+// 渲染完成后才开始跟踪写出阶段，绘制阶段的进度由工作池报告。
+func renderTracked(ctx context.Context, w io.Writer, renderer Renderer, grid *Grid) error {
+	tw := &trackedWriter{ctx: ctx, w: w}
+	if err := renderer.Render(ctx, tw, grid); err != nil {
+		return err
+	}
+	if tw.tracker != nil {
+		tw.tracker.Finish()
+	}
+	return nil
+}
+
+// trackedWriter 在第一次写入时开始跟踪写出阶段，写出的字节数计入该阶段
+type trackedWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	tracker *progress.Tracker
+}
+
+func (t *trackedWriter) Write(p []byte) (int, error) {
+	if t.tracker == nil {
+		t.tracker = progress.NewTracker(t.ctx, progress.StageWrite, 1)
+	}
+	n, err := t.w.Write(p)
+	t.tracker.AddBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/encoder"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// RenderOptions 创建渲染器所需的参数
+type RenderOptions struct {
+	Font       fonts.FontConfig
+	Background color.Color
+	// Mono 不为 nil 时图像格式使用该颜色绘制所有字符
+	Mono color.Color
+	// Pool 用于并行渲染，为 nil 时顺序执行
+	Pool *WorkerPool
+	// Encoding 栅格图像的编码参数
+	Encoding encoder.Options
+	// Transparent 为 true 时使用透明背景，只有 Alpha 格式支持
+	Transparent bool
+	// Padding 栅格图像四周留白的像素数
+	Padding int
+	// ANSI ANSI 文本的颜色数量
+	ANSI ColorDepth
+	// ANSIBackground 为 true 时 ANSI 文本在每个字符后绘制 Background
+	ANSIBackground bool
+	// HTML HTML 输出的参数
+	HTML HTMLOptions
+	// SVGFont SVG 输出处理字体的方式：reference、embed 或 outline
+	SVGFont string
+	// SAUCE .ans 和 XBin 输出追加的元数据
+	SAUCE SAUCE
+	// Charset 生成网格的字符梯度，导出格式记录该字符集
+	Charset []rune
+	// Config 生成网格时使用的配置，导出格式记录该配置
+	Config *config.Config
+}
+
+// Format 已注册的输出格式
+type Format struct {
+	// Name 格式名称，用于 -format 参数
+	Name string
+	// Extensions 该格式的文件扩展名，包含点号，例如 ".txt"
+	Extensions []string
+	// Alpha 格式是否支持透明背景
+	Alpha bool
+	// New 创建该格式的渲染器
+	New func(opts RenderOptions) Renderer
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "text",
+		Extensions: []string{".txt"},
+		New: func(opts RenderOptions) Renderer {
+			return &TextRenderer{Pool: opts.Pool}
+		},
+	})
+	RegisterFormat(Format{
+		Name: "ansi",
+		New: func(opts RenderOptions) Renderer {
+			r := &ANSIRenderer{Depth: opts.ANSI, Pool: opts.Pool}
+			if opts.ANSIBackground {
+				r.Background = opts.Background
+			}
+			return r
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "html",
+		Extensions: []string{".html", ".htm"},
+		Alpha:      true,
+		New: func(opts RenderOptions) Renderer {
+			return &HTMLRenderer{Options: opts.HTML, Background: opts.Background, Mono: opts.Mono, Pool: opts.Pool}
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "svg",
+		Extensions: []string{".svg"},
+		Alpha:      true,
+		New: func(opts RenderOptions) Renderer {
+			return &SVGRenderer{Font: opts.Font, FontMode: opts.SVGFont, Background: opts.Background, Mono: opts.Mono, Pool: opts.Pool}
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "json",
+		Extensions: []string{".json"},
+		New: func(opts RenderOptions) Renderer {
+			return &GridJSONRenderer{Options: opts}
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "grid",
+		Extensions: []string{".grid"},
+		New: func(opts RenderOptions) Renderer {
+			return &GridBinaryRenderer{Options: opts}
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "ans",
+		Extensions: []string{".ans"},
+		New: func(opts RenderOptions) Renderer {
+			return &ANSArtRenderer{SAUCE: opts.SAUCE, Charset: opts.Charset, Background: opts.Background, Pool: opts.Pool}
+		},
+	})
+	RegisterFormat(Format{
+		Name:       "xbin",
+		Extensions: []string{".xb", ".xbin"},
+		New: func(opts RenderOptions) Renderer {
+			return &XBinRenderer{SAUCE: opts.SAUCE, Charset: opts.Charset, Background: opts.Background}
+		},
+	})
+	for _, f := range encoder.All() {
+		registerRaster(f)
+	}
+}
+
+// registerRaster 注册栅格图像格式，绘制的图像由编码层编码
+func registerRaster(f encoder.Format) {
+	RegisterFormat(Format{
+		Name:       f.Name,
+		Extensions: f.Extensions,
+		Alpha:      f.Alpha,
+		New: func(opts RenderOptions) Renderer {
+			return &ImageRenderer{
+				Font:       opts.Font,
+				Background: opts.Background,
+				Mono:       opts.Mono,
+				Pool:       opts.Pool,
+				Padding:    opts.Padding,
+				Encode: func(w io.Writer, img image.Image) error {
+					return f.Encode(w, img, opts.Encoding)
+				},
+			}
+		},
+	})
+}
+
+// NewRenderer 按 opts 创建 f 的渲染器，f 不支持透明背景而 opts 要求透明时返回错误
+func NewRenderer(f Format, opts RenderOptions) (Renderer, error) {
+	if opts.Transparent && !f.Alpha {
+		return nil, fmt.Errorf("output format %s does not support transparent backgrounds", f.Name)
+	}
+	return f.New(opts), nil
+}
+
+// RegisterFormat 注册输出格式，通常在 init 中调用。
+// 名称或扩展名已被其他格式注册时 panic。
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if f.Name == "" || f.New == nil {
+		panic("converter: RegisterFormat requires a name and a renderer")
+	}
+	if _, dup := formats[f.Name]; dup {
+		panic("converter: RegisterFormat called twice for format " + f.Name)
+	}
+	exts := make([]string, len(f.Extensions))
+	for i, ext := range f.Extensions {
+		exts[i] = strings.ToLower(ext)
+		for _, other := range formats {
+			for _, otherExt := range other.Extensions {
+				if otherExt == exts[i] {
+					panic("converter: extension " + ext + " already registered by format " + other.Name)
+				}
+			}
+		}
+	}
+	f.Extensions = exts
+	formats[f.Name] = f
+}
+
+// LookupFormat 按名称查找输出格式
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// FormatForPath 按文件扩展名查找输出格式，扩展名不区分大小写
+func FormatForPath(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return Format{}, false
+	}
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// Formats 返回按名称排序的所有已注册格式
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	list := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// resolveFormat 确定输出格式：优先使用 name，其次按 path 的扩展名识别，最后使用 fallback。
+// fallback 为空且无法识别时返回错误。
+func resolveFormat(name, path, fallback string) (Format, error) {
+	if name != "" {
+		f, ok := LookupFormat(name)
+		if !ok {
+			return Format{}, fmt.Errorf("unsupported output format: %s", name)
+		}
+		return f, nil
+	}
+	if f, ok := FormatForPath(path); ok {
+		return f, nil
+	}
+	if fallback != "" {
+		return resolveFormat(fallback, path, "")
+	}
+	return Format{}, fmt.Errorf("unsupported output format: %s", filepath.Ext(path))
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试内置格式按名称和扩展名查找
+func TestLookupFormat(t *testing.T) {
+	for _, name := range []string{"text", "jpeg"} {
+		if _, ok := LookupFormat(name); !ok {
+			t.Errorf("expected built-in format %s", name)
+		}
+	}
+	tests := map[string]string{
+		"out.txt":      "text",
+		"out.JPG":      "jpeg",
+		"dir/out.jpeg": "jpeg",
+	}
+	for path, expected := range tests {
+		if f, ok := FormatForPath(path); !ok || f.Name != expected {
+			t.Errorf("%s: expected %s, got %q", path, expected, f.Name)
+		}
+	}
+	if _, ok := FormatForPath("out"); ok {
+		t.Error("expected no format for a path without extension")
+	}
+}
+
+// 测试输出格式的优先级：显式名称、扩展名、默认格式
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		name, path, fallback string
+		expected             string
+	}{
+		{"text", "out.jpg", "", "text"},
+		{"", "out.jpg", "text", "jpeg"},
+		{"", "out", "text", "text"},
+		{"", "out.xyz", "text", "text"},
+	}
+	for _, test := range tests {
+		f, err := resolveFormat(test.name, test.path, test.fallback)
+		if err != nil || f.Name != test.expected {
+			t.Errorf("resolveFormat(%q, %q, %q): expected %s, got %q, %v", test.name, test.path, test.fallback, test.expected, f.Name, err)
+		}
+	}
+	if _, err := resolveFormat("", "out.xyz", ""); err == nil {
+		t.Error("expected error for unknown extension without fallback")
+	}
+	if _, err := resolveFormat("xyz", "out.jpg", "text"); err == nil {
+		t.Error("expected error for unknown format name")
+	}
+}
+
+// countRenderer 输出网格尺寸的测试格式
+type countRenderer struct{}
+
+func (countRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	_, err := fmt.Fprintf(w, "%dx%d", grid.Cols, grid.Rows)
+	return err
+}
+
+// 测试注册的格式可用于转换，重复注册时 panic
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat(Format{
+		Name:       "test-count",
+		Extensions: []string{".Count"},
+		New:        func(opts RenderOptions) Renderer { return countRenderer{} },
+	})
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.jpg")
+	if err := createTestImage(inputPath); err != nil {
+		t.Fatalf("failed to create test image: %v", err)
+	}
+	cfg := MockConfig(inputPath, filepath.Join(dir, "output.count"), 10, 1, "simple", "black")
+	if err := ImageToImageColor(cfg); err != nil {
+		t.Fatalf("ImageToImageColor failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if string(data) != "10x5" {
+		t.Errorf("expected 10x5, got %q", data)
+	}
+
+	for _, f := range []Format{
+		{Name: "test-count", New: func(opts RenderOptions) Renderer { return countRenderer{} }},
+		{Name: "test-other", Extensions: []string{".txt"}, New: func(opts RenderOptions) Renderer { return countRenderer{} }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic registering %s", f.Name)
+				}
+			}()
+			RegisterFormat(f)
+		}()
+	}
+}
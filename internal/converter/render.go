@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+
+	"github.com/hai119/Go-ASCII-generator/internal/encoder"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// Renderer 将字符网格输出为某种格式
+type Renderer interface {
+	Render(ctx context.Context, w io.Writer, grid *Grid) error
+}
+
+// TextRenderer 将字符网格输出为文本；单元格指定了背景色时输出 ANSI 真彩色文本
+type TextRenderer struct {
+	// Pool 用于并行生成 ANSI 文本，为 nil 时顺序执行
+	Pool *WorkerPool
+}
+
+// Render 实现 Renderer 接口
+func (r *TextRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	if grid.HasBackground() {
+		return (&ANSIRenderer{Depth: ColorTrue, Pool: r.Pool}).Render(ctx, w, grid)
+	}
+	var b strings.Builder
+	for i := 0; i < grid.Rows; i++ {
+		for _, c := range grid.Row(i) {
+			b.WriteRune(c.Rune)
+		}
+		b.WriteString("\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// ImageRenderer 将字符网格绘制为与源图像同尺寸的图像并编码输出
+type ImageRenderer struct {
+	Font       fonts.FontConfig
+	Background color.Color
+	// Mono 不为 nil 时所有字符使用该颜色绘制，否则使用单元格颜色
+	Mono color.Color
+	// Encode 编码绘制好的图像，为 nil 时以默认参数输出 JPEG
+	Encode func(w io.Writer, img image.Image) error
+	// Pool 用于按行分块并行绘制，为 nil 时顺序执行
+	Pool *WorkerPool
+	// Padding 图像四周以背景色留白的像素数
+	Padding int
+}
+
+// Render 实现 Renderer 接口
+func (r *ImageRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	img, err := r.Draw(ctx, grid)
+	if err != nil {
+		return err
+	}
+	encode := r.Encode
+	if encode == nil {
+		encode = func(w io.Writer, img image.Image) error {
+			f, _ := encoder.Lookup("jpeg")
+			return f.Encode(w, img, encoder.Options{})
+		}
+	}
+	if err := encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode output image: %v", err)
+	}
+	return nil
+}
+
+// Draw 将字符网格绘制为图像
+func (r *ImageRenderer) Draw(ctx context.Context, grid *Grid) (image.Image, error) {
+	img, err := drawGrid(ctx, r.Pool, grid, drawOptions{
+		font:       r.Font,
+		background: r.Background,
+		mono:       r.Mono,
+	})
+	if err != nil || r.Padding <= 0 {
+		return img, err
+	}
+	bounds := img.Bounds()
+	padded := image.NewRGBA(image.Rect(0, 0, bounds.Dx()+2*r.Padding, bounds.Dy()+2*r.Padding))
+	draw.Draw(padded, padded.Bounds(), image.NewUniform(r.Background), image.Point{}, draw.Src)
+	draw.Draw(padded, bounds.Add(image.Pt(r.Padding, r.Padding)), img, bounds.Min, draw.Src)
+	return padded, nil
+}
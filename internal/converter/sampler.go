@@ -0,0 +1,310 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// colorSpace 统计区域亮度和平均颜色时使用的色彩空间
+type colorSpace int
+
+const (
+	// srgbSpace 直接平均 gamma 编码的 sRGB 分量，亮度使用 Rec.601 权重
+	srgbSpace colorSpace = iota
+	// linearSpace 先线性化再平均，亮度为相对亮度 Y 重新编码到 sRGB 曲线
+	linearSpace
+	// oklabSpace 在 OKLab 中平均，亮度为感知明度 L
+	oklabSpace
+)
+
+// parseColorSpace 解析配置中的色彩空间名称
+func parseColorSpace(name string) (colorSpace, error) {
+	switch name {
+	case "", "srgb":
+		return srgbSpace, nil
+	case "linear":
+		return linearSpace, nil
+	case "oklab":
+		return oklabSpace, nil
+	default:
+		return 0, fmt.Errorf("unsupported color space: %s", name)
+	}
+}
+
+// srgbToLinearTable 8 位 sRGB 分量到线性值的查找表
+var srgbToLinearTable = func() [256]float64 {
+	var table [256]float64
+	for i := range table {
+		table[i] = srgbToLinear(float64(i) / 255)
+	}
+	return table
+}()
+
+// srgbToLinear 按 sRGB 传递函数将 [0, 1] 的编码值转换为线性值
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB 将线性值编码回 sRGB 曲线，结果限制在 [0, 1]
+func linearToSRGB(v float64) float64 {
+	v = clampFloat(v, 0, 1)
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// linearToOklab 将线性 sRGB 转换为 OKLab
+func linearToOklab(r, g, b float64) (l, a, bb float64) {
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc,
+		1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc,
+		0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+}
+
+// oklabToLinear 将 OKLab 转换回线性 sRGB，结果可能超出色域
+func oklabToLinear(l, a, bb float64) (r, g, b float64) {
+	lc := l + 0.3963377774*a + 0.2158037573*bb
+	mc := l - 0.1055613458*a - 0.0638541728*bb
+	sc := l - 0.0894841775*a - 1.2914855480*bb
+	lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+	return 4.0767416621*lc - 3.3077115913*mc + 0.2309699292*sc,
+		-1.2684380046*lc + 2.6097574011*mc - 0.3413193965*sc,
+		-0.0041960863*lc - 0.7034186147*mc + 1.7076147010*sc
+}
+
+// colorSum 区域内像素在工作色彩空间中的分量之和
+type colorSum struct {
+	c     [3]float64
+	count float64
+}
+
+// add 合并另一个区域的分量之和
+func (s *colorSum) add(o colorSum) {
+	for i := range s.c {
+		s.c[i] += o.c[i]
+	}
+	s.count += o.count
+}
+
+// mean 返回分量的平均值，空区域返回零值
+func (s colorSum) mean() [3]float64 {
+	if s.count == 0 {
+		return [3]float64{}
+	}
+	return [3]float64{s.c[0] / s.count, s.c[1] / s.count, s.c[2] / s.count}
+}
+
+// sampler 在指定色彩空间中统计图像区域的亮度、平均颜色、颜色方差和亮度标准差。
+// 创建时一次建立颜色分量、亮度以及它们平方的积分图（summed-area table），
+// 任意矩形区域的统计量只需查表四次。
+type sampler struct {
+	img    image.Image
+	space  colorSpace
+	pool   *WorkerPool
+	width  int
+	height int
+	// sums 颜色分量的积分图，大小为 (width+1)*(height+1)，第 0 行和第 0 列为零
+	sums [3][]float64
+	// moments 方差所需的积分图，依次为 c0², c1², c2², 逐像素亮度和亮度²
+	moments [5][]float64
+}
+
+// newSampler 为图像创建采样器并建立全部积分图，
+// pool 用于建表以及之后选择字符时的并行处理，为 nil 时顺序执行。
+// 积分图都在这里建立，之后的查询不会再向工作池提交任务，可以在工作池的任务中调用。
+func newSampler(img image.Image, space colorSpace, pool *WorkerPool) *sampler {
+	bounds := img.Bounds()
+	s := &sampler{img: img, space: space, pool: pool, width: bounds.Dx(), height: bounds.Dy()}
+	planes := make([][]float64, 0, len(s.sums)+len(s.moments))
+	for i := range s.sums {
+		s.sums[i] = make([]float64, (s.width+1)*(s.height+1))
+		planes = append(planes, s.sums[i])
+	}
+	for i := range s.moments {
+		s.moments[i] = make([]float64, (s.width+1)*(s.height+1))
+		planes = append(planes, s.moments[i])
+	}
+	s.buildTables(planes, func(p [3]float64, out []float64) {
+		copy(out, p[:])
+		out[3], out[4], out[5] = p[0]*p[0], p[1]*p[1], p[2]*p[2]
+		v := s.brightnessOf(colorSum{c: p, count: 1})
+		out[6], out[7] = v, v*v
+	})
+	return s
+}
+
+// bounds 返回图像的范围
+func (s *sampler) bounds() image.Rectangle {
+	return s.img.Bounds()
+}
+
+// buildTables 逐像素调用 values 填充各平面，并将每个平面累加为积分图
+func (s *sampler) buildTables(planes [][]float64, values func(p [3]float64, out []float64)) {
+	stride := s.width + 1
+	min := s.img.Bounds().Min
+
+	// 各行先独立计算行内前缀和
+	s.pool.Rows(s.height, func(y int) {
+		out := make([]float64, len(planes))
+		sums := make([]float64, len(planes))
+		row := (y + 1) * stride
+		for x := 0; x < s.width; x++ {
+			values(s.pixel(min.X+x, min.Y+y), out)
+			for i, plane := range planes {
+				sums[i] += out[i]
+				plane[row+x+1] = sums[i]
+			}
+		}
+	})
+
+	// 再沿列方向累加
+	for _, plane := range planes {
+		for y := 2; y <= s.height; y++ {
+			prev, row := plane[(y-1)*stride:y*stride], plane[y*stride:(y+1)*stride]
+			for x := range row {
+				row[x] += prev[x]
+			}
+		}
+	}
+}
+
+// pixel 将像素转换到工作色彩空间：sRGB 为 16 位编码值，线性空间为 [0, 1] 线性值，OKLab 为 (L, a, b)
+func (s *sampler) pixel(x, y int) [3]float64 {
+	r, g, b := s.rgb(x, y)
+	if s.space == srgbSpace {
+		return [3]float64{float64(r), float64(g), float64(b)}
+	}
+	lr, lg, lb := srgbToLinearTable[r>>8], srgbToLinearTable[g>>8], srgbToLinearTable[b>>8]
+	if s.space == linearSpace {
+		return [3]float64{lr, lg, lb}
+	}
+	l, a, bb := linearToOklab(lr, lg, lb)
+	return [3]float64{l, a, bb}
+}
+
+// rgb 返回像素的 16 位 RGB 分量，常见的 RGBA 和 YCbCr 图像直接读取像素数组
+func (s *sampler) rgb(x, y int) (r, g, b uint32) {
+	switch img := s.img.(type) {
+	case *image.RGBA:
+		i := img.PixOffset(x, y)
+		return uint32(img.Pix[i]) * 0x101, uint32(img.Pix[i+1]) * 0x101, uint32(img.Pix[i+2]) * 0x101
+	case *image.YCbCr:
+		yi, ci := img.YOffset(x, y), img.COffset(x, y)
+		r, g, b, _ = color.YCbCr{Y: img.Y[yi], Cb: img.Cb[ci], Cr: img.Cr[ci]}.RGBA()
+		return r, g, b
+	default:
+		r, g, b, _ = s.img.At(x, y).RGBA()
+		return r, g, b
+	}
+}
+
+// region 将区域裁剪到图像范围内，返回积分图坐标，区域为空时 ok 为 false
+func (s *sampler) region(x, y, width, height int) (x0, y0, x1, y1 int, ok bool) {
+	min := s.img.Bounds().Min
+	x0 = clampInt(x-min.X, 0, s.width)
+	y0 = clampInt(y-min.Y, 0, s.height)
+	x1 = clampInt(x+width-min.X, 0, s.width)
+	y1 = clampInt(y+height-min.Y, 0, s.height)
+	return x0, y0, x1, y1, x1 > x0 && y1 > y0
+}
+
+// lookup 查询积分图中矩形区域的和
+func (s *sampler) lookup(plane []float64, x0, y0, x1, y1 int) float64 {
+	stride := s.width + 1
+	return plane[y1*stride+x1] - plane[y0*stride+x1] - plane[y1*stride+x0] + plane[y0*stride+x0]
+}
+
+// sum 返回区域内像素分量之和，区域超出图像的部分被忽略
+func (s *sampler) sum(x, y, width, height int) colorSum {
+	var total colorSum
+	x0, y0, x1, y1, ok := s.region(x, y, width, height)
+	if !ok {
+		return total
+	}
+	for i, plane := range s.sums {
+		total.c[i] = s.lookup(plane, x0, y0, x1, y1)
+	}
+	total.count = float64((x1 - x0) * (y1 - y0))
+	return total
+}
+
+// colorVariance 返回区域内各颜色分量方差之和，单位与工作色彩空间的分量一致
+func (s *sampler) colorVariance(x, y, width, height int) float64 {
+	x0, y0, x1, y1, ok := s.region(x, y, width, height)
+	if !ok {
+		return 0
+	}
+	count := float64((x1 - x0) * (y1 - y0))
+	var variance float64
+	for i := range s.sums {
+		mean := s.lookup(s.sums[i], x0, y0, x1, y1) / count
+		variance += s.lookup(s.moments[i], x0, y0, x1, y1)/count - mean*mean
+	}
+	return math.Max(variance, 0)
+}
+
+// brightnessStdDev 返回区域内逐像素亮度的标准差。
+// 与 calculateContrast 的最大最小亮度之差不同，标准差可以由积分图在常数时间内得到。
+func (s *sampler) brightnessStdDev(x, y, width, height int) float64 {
+	x0, y0, x1, y1, ok := s.region(x, y, width, height)
+	if !ok {
+		return 0
+	}
+	count := float64((x1 - x0) * (y1 - y0))
+	mean := s.lookup(s.moments[3], x0, y0, x1, y1) / count
+	return math.Sqrt(math.Max(s.lookup(s.moments[4], x0, y0, x1, y1)/count-mean*mean, 0))
+}
+
+// brightness 返回区域的平均亮度，范围 [0, 1]
+func (s *sampler) brightness(x, y, width, height int) float64 {
+	return s.brightnessOf(s.sum(x, y, width, height))
+}
+
+// averageColor 返回区域的平均颜色
+func (s *sampler) averageColor(x, y, width, height int) color.Color {
+	return s.colorOf(s.sum(x, y, width, height))
+}
+
+// brightnessOf 由分量之和计算平均亮度
+func (s *sampler) brightnessOf(total colorSum) float64 {
+	m := total.mean()
+	switch s.space {
+	case linearSpace:
+		return linearToSRGB(0.2126*m[0] + 0.7152*m[1] + 0.0722*m[2])
+	case oklabSpace:
+		return clampFloat(m[0], 0, 1)
+	default:
+		return (0.299*m[0] + 0.587*m[1] + 0.114*m[2]) / 65535
+	}
+}
+
+// colorOf 由分量之和计算平均颜色并转换回 sRGB，空区域返回黑色
+func (s *sampler) colorOf(total colorSum) color.Color {
+	if total.count == 0 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	m := total.mean()
+	switch s.space {
+	case linearSpace:
+		return color.RGBA{encode8(m[0]), encode8(m[1]), encode8(m[2]), 255}
+	case oklabSpace:
+		r, g, b := oklabToLinear(m[0], m[1], m[2])
+		return color.RGBA{encode8(r), encode8(g), encode8(b), 255}
+	default:
+		// 与 calculateAverageColor 一致，16 位平均值截断为 8 位
+		return color.RGBA{uint8(uint32(m[0]) >> 8), uint8(uint32(m[1]) >> 8), uint8(uint32(m[2]) >> 8), 255}
+	}
+}
+
+// encode8 将线性分量编码为 8 位 sRGB 值
+func encode8(v float64) uint8 {
+	return uint8(math.Round(linearToSRGB(v) * 255))
+}
@@ -0,0 +1,208 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// halfImage 左半部分为 left、右半部分为 right 的测试图像
+func halfImage(left, right color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+// 测试不同色彩空间下黑白各半区域的亮度
+func TestSamplerBrightness(t *testing.T) {
+	img := halfImage(color.Black, color.White)
+	tests := []struct {
+		space    colorSpace
+		expected float64
+	}{
+		{srgbSpace, 0.5},
+		{linearSpace, linearToSRGB(0.5)},
+		{oklabSpace, 0.5},
+	}
+
+	for _, test := range tests {
+		result := newSampler(img, test.space, nil).brightness(0, 0, 4, 2)
+		if math.Abs(result-test.expected) > 1e-3 {
+			t.Errorf("space %d: expected %f, got %f", test.space, test.expected, result)
+		}
+	}
+}
+
+// 测试不同色彩空间下红绿混合的平均颜色
+func TestSamplerAverageColor(t *testing.T) {
+	img := halfImage(color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255})
+	tests := []struct {
+		space    colorSpace
+		expected color.RGBA
+	}{
+		{srgbSpace, color.RGBA{127, 127, 0, 255}},
+		{linearSpace, color.RGBA{188, 188, 0, 255}},
+	}
+
+	for _, test := range tests {
+		result := newSampler(img, test.space, nil).averageColor(0, 0, 4, 2)
+		if result != test.expected {
+			t.Errorf("space %d: expected %v, got %v", test.space, test.expected, result)
+		}
+	}
+
+	// OKLab 平均后的颜色比 sRGB 平均更亮
+	oklab := newSampler(img, oklabSpace, nil).averageColor(0, 0, 4, 2).(color.RGBA)
+	if oklab.R <= 127 || oklab.G <= 127 {
+		t.Errorf("expected OKLab mix brighter than sRGB mix, got %v", oklab)
+	}
+}
+
+// noiseImage 随机颜色的测试图像
+func noiseImage(width, height int) *image.RGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// 测试积分图的区域统计与逐像素扫描一致，包括 YCbCr 图像和超出边界的区域
+func TestSamplerSummedArea(t *testing.T) {
+	rgba := noiseImage(37, 23)
+	ycbcr := image.NewYCbCr(rgba.Bounds(), image.YCbCrSubsampleRatio420)
+	rng := rand.New(rand.NewSource(2))
+	rng.Read(ycbcr.Y)
+	rng.Read(ycbcr.Cb)
+	rng.Read(ycbcr.Cr)
+
+	regions := []image.Rectangle{
+		image.Rect(0, 0, 37, 23),
+		image.Rect(3, 5, 11, 17),
+		image.Rect(30, 20, 50, 40),
+		image.Rect(36, 0, 37, 1),
+	}
+	for _, img := range []image.Image{rgba, ycbcr} {
+		// 直接读取像素数组的结果与 At 一致
+		s := newSampler(img, srgbSpace, nil)
+		for y := 0; y < 23; y++ {
+			for x := 0; x < 37; x++ {
+				r, g, b := s.rgb(x, y)
+				er, eg, eb, _ := img.At(x, y).RGBA()
+				if r != er || g != eg || b != eb {
+					t.Fatalf("pixel (%d, %d): expected (%d, %d, %d), got (%d, %d, %d)", x, y, er, eg, eb, r, g, b)
+				}
+			}
+		}
+
+		for _, space := range []colorSpace{srgbSpace, linearSpace, oklabSpace} {
+			s := newSampler(img, space, nil)
+			for _, r := range regions {
+				var expected colorSum
+				for y := r.Min.Y; y < r.Max.Y && y < 23; y++ {
+					for x := r.Min.X; x < r.Max.X && x < 37; x++ {
+						expected.add(colorSum{c: s.pixel(x, y), count: 1})
+					}
+				}
+				result := s.sum(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+				if result.count != expected.count {
+					t.Fatalf("space %d region %v: expected %v pixels, got %v", space, r, expected.count, result.count)
+				}
+				for i := range result.c {
+					if math.Abs(result.c[i]-expected.c[i]) > 1e-6*math.Max(1, math.Abs(expected.c[i])) {
+						t.Errorf("space %d region %v channel %d: expected %f, got %f", space, r, i, expected.c[i], result.c[i])
+					}
+				}
+			}
+		}
+	}
+
+}
+
+// 测试 sRGB 空间的积分图统计与原有的逐像素函数一致，
+// 覆盖奇数尺寸的区域、超出右下边界的区域和原点不为 (0, 0) 的子图像
+func TestSamplerPerPixelReference(t *testing.T) {
+	full := noiseImage(41, 29)
+	sub := full.SubImage(image.Rect(5, 3, 38, 26)).(*image.RGBA)
+	for _, img := range []*image.RGBA{full, sub} {
+		b := img.Bounds()
+		regions := []image.Rectangle{
+			image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1),
+			image.Rect(b.Min.X+3, b.Min.Y+5, b.Min.X+10, b.Min.Y+16),
+			image.Rect(b.Min.X+1, b.Min.Y+2, b.Max.X, b.Max.Y),
+			image.Rect(b.Max.X-3, b.Max.Y-2, b.Max.X+6, b.Max.Y+7),
+			b,
+		}
+		s := newSampler(img, srgbSpace, nil)
+		for _, r := range regions {
+			x, y, w, h := r.Min.X, r.Min.Y, r.Dx(), r.Dy()
+			if result, expected := s.averageColor(x, y, w, h), calculateAverageColor(img, x, y, w, h); result != expected {
+				t.Errorf("bounds %v region %v: expected average color %v, got %v", b, r, expected, result)
+			}
+			if result, expected := s.brightness(x, y, w, h), calculateBrightness(img, x, y, w, h); math.Abs(result-expected) > 1e-9 {
+				t.Errorf("bounds %v region %v: expected brightness %f, got %f", b, r, expected, result)
+			}
+			if result, expected := s.colorVariance(x, y, w, h), calculateColorVariance(img, x, y, w, h); math.Abs(result-expected) > 1e-6*math.Max(expected, 1) {
+				t.Errorf("bounds %v region %v: expected color variance %f, got %f", b, r, expected, result)
+			}
+
+			// 逐像素计算亮度的标准差
+			var sum, sumSq, count float64
+			for py := y; py < y+h && py < b.Max.Y; py++ {
+				for px := x; px < x+w && px < b.Max.X; px++ {
+					v := calculateBrightness(img, px, py, 1, 1)
+					sum, sumSq, count = sum+v, sumSq+v*v, count+1
+				}
+			}
+			mean := sum / count
+			expected := math.Sqrt(math.Max(sumSq/count-mean*mean, 0))
+			if result := s.brightnessStdDev(x, y, w, h); math.Abs(result-expected) > 1e-9 {
+				t.Errorf("bounds %v region %v: expected brightness std dev %f, got %f", b, r, expected, result)
+			}
+		}
+	}
+}
+
+// 测试亮度标准差：黑白各半的区域为 0.5，纯色区域为 0
+func TestSamplerBrightnessStdDev(t *testing.T) {
+	s := newSampler(halfImage(color.Black, color.White), srgbSpace, nil)
+	if result := s.brightnessStdDev(0, 0, 4, 2); math.Abs(result-0.5) > 1e-9 {
+		t.Errorf("expected std dev 0.5, got %f", result)
+	}
+	if result := s.brightnessStdDev(0, 0, 2, 2); result != 0 {
+		t.Errorf("expected zero std dev for a flat region, got %f", result)
+	}
+}
+
+// 测试 OKLab 转换可以还原原始颜色
+func TestOklabRoundTrip(t *testing.T) {
+	for _, c := range [][3]float64{{0, 0, 0}, {1, 1, 1}, {0.2, 0.5, 0.8}, {1, 0, 0}} {
+		l, a, b := linearToOklab(c[0], c[1], c[2])
+		r, g, bb := oklabToLinear(l, a, b)
+		if math.Abs(r-c[0]) > 1e-6 || math.Abs(g-c[1]) > 1e-6 || math.Abs(bb-c[2]) > 1e-6 {
+			t.Errorf("round trip of %v gave (%f, %f, %f)", c, r, g, bb)
+		}
+	}
+}
+
+// 测试未知的色彩空间返回错误
+func TestParseColorSpace(t *testing.T) {
+	if _, err := parseColorSpace("hsv"); err == nil {
+		t.Error("expected error for unknown color space")
+	}
+	if space, err := parseColorSpace(""); err != nil || space != srgbSpace {
+		t.Errorf("expected srgb by default, got %d (%v)", space, err)
+	}
+}
@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// cellLayout 图像划分为字符单元格的布局，单元格高度为宽度的两倍
+type cellLayout struct {
+	cellWidth  float64
+	cellHeight float64
+	numRows    int
+	numCols    int
+}
+
+// newCellLayout 根据图像尺寸和列数计算单元格布局
+func newCellLayout(bounds image.Rectangle, numCols int) cellLayout {
+	cellWidth := float64(bounds.Max.X) / float64(numCols)
+	cellHeight := 2 * cellWidth
+	return cellLayout{
+		cellWidth:  cellWidth,
+		cellHeight: cellHeight,
+		numRows:    int(float64(bounds.Max.Y) / cellHeight),
+		numCols:    numCols,
+	}
+}
+
+// cell 返回第 row 行第 col 列单元格的像素区域
+func (l cellLayout) cell(row, col int) (x, y, width, height int) {
+	return int(float64(col) * l.cellWidth),
+		int(float64(row) * l.cellHeight),
+		int(l.cellWidth),
+		int(l.cellHeight)
+}
+
+// glyphSelector 为图像的每个单元格选择字符
+type glyphSelector interface {
+	selectRunes(src *sampler, layout cellLayout) [][]rune
+}
+
+// cellColorer 由字符选择器决定单元格的绘制颜色，例如盲文模式只取点亮的点的颜色
+type cellColorer interface {
+	cellColor(src *sampler, x, y, width, height int, r rune) color.Color
+}
+
+// foregroundColor 返回单元格字符的绘制颜色，默认为单元格的平均颜色
+func foregroundColor(selector glyphSelector, src *sampler, x, y, width, height int, r rune) color.Color {
+	if colorer, ok := selector.(cellColorer); ok {
+		return colorer.cellColor(src, x, y, width, height, r)
+	}
+	return src.averageColor(x, y, width, height)
+}
+
+// cellPainter 由字符选择器同时决定单元格的前景色和背景色，例如块元素模式
+// 文本输出使用 ANSI 颜色，图像输出按子单元格直接填充。
+type cellPainter interface {
+	cellColors(src *sampler, x, y, width, height int, r rune) (fg, bg color.Color)
+}
+
+// newGlyphSelector 根据配置的字形模式创建字符选择器
+func newGlyphSelector(cs *charset, cfg *config.Config) (glyphSelector, error) {
+	switch cfg.GlyphMode {
+	case "", "brightness":
+		return newBrightnessSelector(cs, cfg)
+	case "structure":
+		return newStructureMatcher(cs, cfg)
+	case "edge":
+		return newEdgeSelector(cs, cfg)
+	case "braille":
+		return newBrailleSelector(cfg)
+	case "halfblock":
+		return &blockSelector{shape: halfBlockShape}, nil
+	case "quadrant":
+		return &blockSelector{shape: quadrantShape}, nil
+	case "sextant":
+		return &blockSelector{shape: sextantShape}, nil
+	default:
+		return nil, fmt.Errorf("unsupported glyph mode: %s", cfg.GlyphMode)
+	}
+}
+
+// sampleSubcells 将每个单元格划分为 cols x rows 个子单元格并采样平均亮度，
+// 返回按行排列的 (numCols*cols) x (numRows*rows) 亮度网格
+func sampleSubcells(src *sampler, layout cellLayout, cols, rows int) []float64 {
+	width := layout.numCols * cols
+	values := make([]float64, width*layout.numRows*rows)
+	src.pool.Rows(layout.numRows, func(i int) {
+		for j := 0; j < layout.numCols; j++ {
+			x, y, cw, ch := layout.cell(i, j)
+			grid := sampleGrid(src, x, y, cw, ch, cols, rows)
+			for row := 0; row < rows; row++ {
+				copy(values[(i*rows+row)*width+j*cols:], grid[row*cols:(row+1)*cols])
+			}
+		}
+	})
+	return values
+}
+
+// brightnessSelector 按单元格平均亮度在字符梯度中选择字符，量化前经过色调映射并可选抖动
+type brightnessSelector struct {
+	chars []rune
+	tone  *toneMap
+	quant *quantizer
+}
+
+// newBrightnessSelector 根据配置创建亮度选择器
+func newBrightnessSelector(cs *charset, cfg *config.Config) (*brightnessSelector, error) {
+	tone, err := newToneMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	quant, err := newQuantizer(cfg.Dither)
+	if err != nil {
+		return nil, err
+	}
+	return &brightnessSelector{chars: cs.chars, tone: tone, quant: quant}, nil
+}
+
+func (s *brightnessSelector) selectRunes(src *sampler, layout cellLayout) [][]rune {
+	values := sampleSubcells(src, layout, 1, 1)
+	s.tone.apply(values, layout.numCols, layout.numRows)
+
+	// 在单元格空间中量化（可抖动）后再查找字符
+	indices := s.quant.quantize(values, layout.numCols, layout.numRows, len(s.chars))
+	runes := make([][]rune, layout.numRows)
+	for i := range runes {
+		runes[i] = make([]rune, layout.numCols)
+		for j := range runes[i] {
+			runes[i][j] = s.chars[indices[i*layout.numCols+j]]
+		}
+	}
+	return runes
+}
@@ -0,0 +1,225 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// DecodeVideo 使用 ffmpeg 以每秒 fps 帧解码 r 中的视频，按顺序对每一帧调用 fn。
+// ffmpeg 通过管道输出 PPM 图像，不需要临时文件；fn 返回错误或 ctx 取消时终止 ffmpeg。
+// 动画 GIF 直接解码并按帧延迟重新采样，不需要 ffmpeg。
+func DecodeVideo(ctx context.Context, r io.Reader, fps int, fn func(index int, img image.Image) error) error {
+	br := bufio.NewReader(r)
+	if isGIF(br) {
+		anim, err := DecodeGIF(br)
+		if err != nil {
+			return err
+		}
+		for index, img := range anim.Sample(fps) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(index, img); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-vf", fmt.Sprintf("fps=%d", fps),
+		"-f", "image2pipe",
+		"-c:v", "ppm",
+		"pipe:1")
+	cmd.Stdin = br
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to decode video: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+
+	frames := bufio.NewReader(stdout)
+	for index := 0; ; index++ {
+		if _, err := frames.Peek(1); err == io.EOF {
+			break
+		}
+		img, err := readPPM(frames)
+		if err == nil {
+			err = fn(index, img)
+		}
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to decode video: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// VideoEncoder 将图像帧通过管道交给 ffmpeg 编码为 H.264 视频
+// 帧以原始 RGBA 数据写入，所有帧的尺寸必须与第一帧相同。
+type VideoEncoder struct {
+	ctx    context.Context
+	w      io.Writer
+	fps    int
+	format string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	bounds image.Rectangle
+	// waitOnce 保证 WriteFrame 出错后再调用 Close 或 Abort 时只等待 ffmpeg 一次
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// NewVideoEncoder 创建以每秒 fps 帧编码视频并写入 w 的编码器，format 为 ffmpeg 的输出格式，例如 mp4。
+// ffmpeg 在写入第一帧时启动。
+func NewVideoEncoder(ctx context.Context, w io.Writer, fps int, format string) *VideoEncoder {
+	return &VideoEncoder{ctx: ctx, w: w, fps: fps, format: format}
+}
+
+// WriteFrame 写入一帧
+func (e *VideoEncoder) WriteFrame(img image.Image) error {
+	if e.cmd == nil {
+		if err := e.start(img.Bounds()); err != nil {
+			return err
+		}
+	}
+	if img.Bounds().Size() != e.bounds.Size() {
+		return fmt.Errorf("frame size %v differs from first frame %v", img.Bounds().Size(), e.bounds.Size())
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != 4*rgba.Rect.Dx() {
+		rgba = image.NewRGBA(image.Rect(0, 0, e.bounds.Dx(), e.bounds.Dy()))
+		draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	}
+	if _, err := e.stdin.Write(rgba.Pix); err != nil {
+		return e.wait(fmt.Errorf("failed to write frame: %v", err))
+	}
+	return nil
+}
+
+// Close 结束输入并等待 ffmpeg 完成编码；没有写入任何帧时返回错误
+func (e *VideoEncoder) Close() error {
+	if e.cmd == nil {
+		return fmt.Errorf("no frames to encode")
+	}
+	e.stdin.Close()
+	return e.wait(nil)
+}
+
+// Abort 终止 ffmpeg 而不完成编码，用于出错时放弃输出。
+// 与 Close 不同，不会把已经编码的帧写成完整的视频；之前已经写入 w 的数据不会被撤回。
+func (e *VideoEncoder) Abort() {
+	if e.cmd == nil {
+		return
+	}
+	e.cmd.Process.Kill()
+	e.stdin.Close()
+	e.wait(nil)
+}
+
+// start 按第一帧的尺寸启动 ffmpeg
+func (e *VideoEncoder) start(bounds image.Rectangle) error {
+	args := []string{
+		"-loglevel", "error",
+		"-f", "rawvideo",
+		"-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%dx%d", bounds.Dx(), bounds.Dy()),
+		"-framerate", strconv.Itoa(e.fps),
+		"-i", "pipe:0",
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		// H.264 要求宽高为偶数
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2",
+	}
+	if e.format == "mp4" {
+		// 管道不能回写文件头，使用分段 MP4
+		args = append(args, "-movflags", "frag_keyframe+empty_moov")
+	}
+	args = append(args, "-f", e.format, "pipe:1")
+
+	// 启动成功后才设置 e.cmd，Close 和 Abort 据此判断 ffmpeg 是否在运行
+	cmd := exec.CommandContext(e.ctx, "ffmpeg", args...)
+	cmd.Stdout = e.w
+	cmd.Stderr = &e.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to encode video: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+	e.cmd = cmd
+	e.stdin = stdin
+	e.bounds = bounds
+	return nil
+}
+
+// wait 等待 ffmpeg 退出，返回 ctx 的错误、ffmpeg 的错误或 err；多次调用时只等待一次
+func (e *VideoEncoder) wait(err error) error {
+	e.waitOnce.Do(func() {
+		e.waitErr = e.cmd.Wait()
+	})
+	if e.waitErr != nil {
+		if e.ctx.Err() != nil {
+			return e.ctx.Err()
+		}
+		return fmt.Errorf("failed to encode video: %v: %s", e.waitErr, bytes.TrimSpace(e.stderr.Bytes()))
+	}
+	return err
+}
+
+// readPPM 从 r 中读取一幅二进制 PPM（P6）图像，最大值为 255
+func readPPM(r *bufio.Reader) (image.Image, error) {
+	var magic string
+	var width, height, maxValue int
+	if _, err := fmt.Fscan(r, &magic, &width, &height, &maxValue); err != nil {
+		return nil, fmt.Errorf("failed to read frame header: %v", err)
+	}
+	if magic != "P6" || maxValue != 255 || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("unsupported frame format %s %dx%d max %d", magic, width, height, maxValue)
+	}
+	// 头部以单个空白字符结束
+	if _, err := r.ReadByte(); err != nil {
+		return nil, fmt.Errorf("failed to read frame header: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := make([]byte, 3*width)
+	for y := 0; y < height; y++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, fmt.Errorf("failed to read frame: %v", err)
+		}
+		pix := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			pix[4*x], pix[4*x+1], pix[4*x+2], pix[4*x+3] = row[3*x], row[3*x+1], row[3*x+2], 255
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeFFmpeg 在 PATH 的最前面放入执行 script 的 ffmpeg
+func fakeFFmpeg(t *testing.T, script string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// 测试 Abort 终止 ffmpeg 而不写出结尾，Close 则等待 ffmpeg 完成输出
+func TestVideoEncoderAbort(t *testing.T) {
+	fakeFFmpeg(t, "cat >/dev/null\necho finalized")
+	frame := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	var closed bytes.Buffer
+	encoder := NewVideoEncoder(context.Background(), &closed, 10, "mp4")
+	if err := encoder.WriteFrame(frame); err != nil {
+		t.Fatalf("WriteFrame failed: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !strings.Contains(closed.String(), "finalized") {
+		t.Errorf("expected Close to finalize the output, got %q", closed.String())
+	}
+
+	var aborted bytes.Buffer
+	encoder = NewVideoEncoder(context.Background(), &aborted, 10, "mp4")
+	if err := encoder.WriteFrame(frame); err != nil {
+		t.Fatalf("WriteFrame failed: %v", err)
+	}
+	encoder.Abort()
+	if aborted.Len() != 0 {
+		t.Errorf("expected no output after Abort, got %q", aborted.String())
+	}
+
+	// 没有启动 ffmpeg 时 Abort 不做任何事
+	NewVideoEncoder(context.Background(), &aborted, 10, "mp4").Abort()
+}
+
+// 测试 WriteFrame 因 ffmpeg 退出而失败后，Close 返回同一个 ffmpeg 错误而不是重复等待的错误
+func TestVideoEncoderWriteError(t *testing.T) {
+	fakeFFmpeg(t, "echo broken >&2\nexit 1")
+	encoder := NewVideoEncoder(context.Background(), &bytes.Buffer{}, 10, "mp4")
+
+	// 帧大于管道缓冲区，ffmpeg 退出后写入必然失败
+	frame := image.NewRGBA(image.Rect(0, 0, 1024, 1024))
+	var err error
+	for i := 0; i < 4 && err == nil; i++ {
+		err = encoder.WriteFrame(frame)
+	}
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected ffmpeg error from WriteFrame, got %v", err)
+	}
+	if err := encoder.Close(); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected Close to return the ffmpeg error, got %v", err)
+	}
+	encoder.Abort()
+}
@@ -0,0 +1,158 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// 字形模板的分辨率，宽高比与单元格一致（1:2）
+const (
+	templateCols = 8
+	templateRows = 16
+)
+
+// templateCache 按字体和字符集缓存字形模板
+var templateCache = struct {
+	sync.Mutex
+	templates map[string][][]float64
+}{templates: make(map[string][][]float64)}
+
+// structureMatcher 按字形形状匹配单元格：将每个单元格降采样为与字形模板相同的分辨率，
+// 选择平方差之和（SSD）最小的字形，从而保留边缘和斜线等结构。
+type structureMatcher struct {
+	chars     []rune
+	templates [][]float64
+	tone      *toneMap
+}
+
+// newStructureMatcher 为字符集构建形状匹配器
+func newStructureMatcher(cs *charset, cfg *config.Config) (*structureMatcher, error) {
+	tone, err := newToneMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := glyphTemplates(cs)
+	if err != nil {
+		return nil, err
+	}
+	return &structureMatcher{chars: cs.chars, templates: templates, tone: tone}, nil
+}
+
+// glyphTemplates 光栅化字符集中的每个字形并降采样为模板，值为墨迹覆盖率 [0, 1]
+func glyphTemplates(cs *charset) ([][]float64, error) {
+	key := fmt.Sprintf("%s|%s", cs.font.Path, string(cs.chars))
+
+	templateCache.Lock()
+	defer templateCache.Unlock()
+	if templates, ok := templateCache.templates[key]; ok {
+		return templates, nil
+	}
+
+	face, err := fonts.LoadFace(fonts.FontConfig{Path: cs.font.Path, Size: calibrationSize})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	width, height := fonts.CellSize(face, cs.chars)
+	templates := make([][]float64, len(cs.chars))
+	for i, r := range cs.chars {
+		templates[i] = downsampleAlpha(fonts.RasterizeGlyph(face, r, width, height), templateCols, templateRows)
+	}
+
+	templateCache.templates[key] = templates
+	return templates, nil
+}
+
+// downsampleAlpha 将覆盖率位图按区域平均降采样为 cols x rows 的网格
+func downsampleAlpha(img *image.Alpha, cols, rows int) []float64 {
+	bounds := img.Bounds()
+	grid := make([]float64, cols*rows)
+	for ty := 0; ty < rows; ty++ {
+		y0, y1 := subRange(0, bounds.Dy(), ty, rows)
+		for tx := 0; tx < cols; tx++ {
+			x0, x1 := subRange(0, bounds.Dx(), tx, cols)
+			var sum, count int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sum += int(img.AlphaAt(x, y).A)
+					count++
+				}
+			}
+			if count > 0 {
+				grid[ty*cols+tx] = float64(sum) / float64(255*count)
+			}
+		}
+	}
+	return grid
+}
+
+// subRange 将 [start, start+length) 均分为 parts 段，返回第 index 段的范围，每段至少一个像素
+func subRange(start, length, index, parts int) (int, int) {
+	lo := start + index*length/parts
+	hi := start + (index+1)*length/parts
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// sampleGrid 将单元格划分为 cols x rows 个子区域并返回每个子区域的平均亮度
+func sampleGrid(src *sampler, x, y, width, height, cols, rows int) []float64 {
+	grid := make([]float64, cols*rows)
+	for ty := 0; ty < rows; ty++ {
+		y0, y1 := subRange(y, height, ty, rows)
+		for tx := 0; tx < cols; tx++ {
+			x0, x1 := subRange(x, width, tx, cols)
+			grid[ty*cols+tx] = src.brightness(x0, y0, x1-x0, y1-y0)
+		}
+	}
+	return grid
+}
+
+func (m *structureMatcher) selectRunes(src *sampler, layout cellLayout) [][]rune {
+	width, height := layout.numCols*templateCols, layout.numRows*templateRows
+	values := sampleSubcells(src, layout, templateCols, templateRows)
+	m.tone.apply(values, width, height)
+
+	// 按行并行匹配，适用于视频的逐帧处理
+	runes := make([][]rune, layout.numRows)
+	src.pool.Rows(layout.numRows, func(i int) {
+		runes[i] = make([]rune, layout.numCols)
+		cell := make([]float64, templateCols*templateRows)
+		for j := range runes[i] {
+			// 字形墨迹对应暗部，与亮度梯度从浓到淡的方向一致
+			for row := 0; row < templateRows; row++ {
+				for col := 0; col < templateCols; col++ {
+					cell[row*templateCols+col] = 1 - values[(i*templateRows+row)*width+j*templateCols+col]
+				}
+			}
+			runes[i][j] = m.chars[m.bestMatch(cell)]
+		}
+	})
+	return runes
+}
+
+// bestMatch 返回与单元格平方差之和最小的字形下标
+func (m *structureMatcher) bestMatch(cell []float64) int {
+	best := 0
+	bestScore := -1.0
+	for i, template := range m.templates {
+		var score float64
+		for k, v := range template {
+			d := v - cell[k]
+			score += d * d
+			if bestScore >= 0 && score >= bestScore {
+				break
+			}
+		}
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// 生成白底黑色线条的测试图像
+func lineImage(width, height int, line image.Rectangle) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, line, &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	return img
+}
+
+// 测试形状匹配能区分竖线和横线
+func TestStructureMatcher(t *testing.T) {
+	cs := &charset{chars: []rune("|-_ "), font: fonts.GetFontConfig("english", 1)}
+	matcher, err := newStructureMatcher(cs, &config.Config{Background: "black"})
+	if err != nil {
+		t.Fatalf("newStructureMatcher failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		line     image.Rectangle
+		expected rune
+	}{
+		{"vertical", image.Rect(36, 0, 44, 160), '|'},
+		{"horizontal", image.Rect(10, 80, 70, 100), '-'},
+		{"empty", image.Rectangle{}, ' '},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := lineImage(80, 160, test.line)
+			runes := matcher.selectRunes(newSampler(img, srgbSpace, nil), newCellLayout(img.Bounds(), 1))
+			if len(runes) != 1 || len(runes[0]) != 1 {
+				t.Fatalf("expected a 1x1 grid, got %v", runes)
+			}
+			if runes[0][0] != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, runes[0][0])
+			}
+		})
+	}
+}
+
+// 测试形状匹配的输出尺寸与单元格布局一致
+func TestStructureMatcherLayout(t *testing.T) {
+	cfg := &config.Config{Language: "english", CharMode: "simple", Scale: 1, GlyphMode: "structure"}
+	cs, err := resolveCharset(cfg)
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	selector, err := newGlyphSelector(cs, cfg)
+	if err != nil {
+		t.Fatalf("newGlyphSelector failed: %v", err)
+	}
+
+	img := generateTestImage(200, 120, color.RGBA{128, 128, 128, 255})
+	layout := newCellLayout(img.Bounds(), 20)
+	runes := selector.selectRunes(newSampler(img, srgbSpace, nil), layout)
+	if len(runes) != layout.numRows {
+		t.Fatalf("expected %d rows, got %d", layout.numRows, len(runes))
+	}
+	for i, row := range runes {
+		if len(row) != layout.numCols {
+			t.Errorf("row %d: expected %d columns, got %d", i, layout.numCols, len(row))
+		}
+	}
+}
+
+// 测试未知字形模式返回错误
+func TestNewGlyphSelectorUnknown(t *testing.T) {
+	cs := &charset{chars: []rune(SimpleChars)}
+	if _, err := newGlyphSelector(cs, &config.Config{GlyphMode: "unknown"}); err == nil {
+		t.Error("expected error for unknown glyph mode")
+	}
+}
@@ -0,0 +1,329 @@
+package converter
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// SVG 输出处理字体的方式
+const (
+	// SVGFontReference 按字体名称引用，查看者需要安装该字体
+	SVGFontReference = "reference"
+	// SVGFontEmbed 将字体文件以 data URI 嵌入 SVG
+	SVGFontEmbed = "embed"
+	// SVGFontOutline 将字形转换为路径，不依赖字体
+	SVGFontOutline = "outline"
+)
+
+// parseSVGFont 检查 SVG 字体处理方式，空字符串表示 reference
+func parseSVGFont(name string) (string, error) {
+	switch name {
+	case "":
+		return SVGFontReference, nil
+	case SVGFontReference, SVGFontEmbed, SVGFontOutline:
+		return name, nil
+	default:
+		return "", fmt.Errorf("unsupported SVG font mode: %s", name)
+	}
+}
+
+// SVGRenderer 将字符网格输出为与源图像同尺寸的 SVG。
+// 背景色相同的连续单元格合并为一个矩形，颜色相同的连续字符合并为一个 <text> 或 <g> 元素。
+type SVGRenderer struct {
+	Font fonts.FontConfig
+	// FontMode 字体处理方式：reference、embed 或 outline，为空时使用 reference
+	FontMode   string
+	Background color.Color
+	// Mono 不为 nil 时所有字符使用该颜色绘制，否则使用单元格颜色
+	Mono color.Color
+	// Pool 用于并行生成元素，为 nil 时顺序执行
+	Pool *WorkerPool
+}
+
+// Render 实现 Renderer 接口
+func (r *SVGRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	mode, err := parseSVGFont(r.FontMode)
+	if err != nil {
+		return err
+	}
+	f, err := fonts.LoadTrueType(r.Font)
+	if err != nil {
+		return err
+	}
+	// 与 drawRow 相同，字符按字体行高在单元格内垂直居中
+	metrics := truetype.NewFace(f, &truetype.Options{Size: r.Font.Size}).Metrics()
+	baseline := grid.CellHeight/2 + float64(metrics.Height)/64/2
+
+	chunks, err := r.Pool.MapContext(ctx, grid.Rows, r.Pool.rowChunk(grid.Rows), func(start, end int) interface{} {
+		var chunk strings.Builder
+		for i := start; i < end; i++ {
+			r.writeRow(&chunk, grid, i, float64(i)*grid.CellHeight+baseline, mode == SVGFontOutline)
+		}
+		return chunk.String()
+	})
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		grid.Width, grid.Height, grid.Width, grid.Height)
+	switch mode {
+	case SVGFontOutline:
+		r.writeGlyphDefs(&b, f, grid)
+	case SVGFontEmbed:
+		data, err := os.ReadFile(r.Font.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load font %s: %v", r.Font.Path, err)
+		}
+		fmt.Fprintf(&b, "<style>@font-face { font-family: \"ascii-art\"; src: url(data:font/ttf;base64,%s); }\n", base64.StdEncoding.EncodeToString(data))
+		fmt.Fprintf(&b, "text { font-family: \"ascii-art\", monospace; font-size: %spx; }</style>\n", svgNumber(r.Font.Size))
+	default:
+		weight := "normal"
+		if strings.Contains(strings.ToLower(f.Name(truetype.NameIDFontSubfamily)), "bold") {
+			weight = "bold"
+		}
+		fmt.Fprintf(&b, "<style>text { font-family: \"%s\", monospace; font-weight: %s; font-size: %spx; }</style>\n",
+			svgEscape(f.Name(truetype.NameIDFontFamily)), weight, svgNumber(r.Font.Size))
+	}
+	if bg := cssColor(r.background()); bg != "transparent" {
+		fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", bg)
+	}
+	for _, chunk := range chunks {
+		b.WriteString(chunk.(string))
+	}
+	b.WriteString("</svg>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// background 返回背景色，未设置时为黑色
+func (r *SVGRenderer) background() color.Color {
+	if r.Background == nil {
+		return color.Black
+	}
+	return r.Background
+}
+
+// drawsGlyph 判断单元格是否需要绘制字形：空白不绘制，有背景色的块元素由矩形表示
+func (r *SVGRenderer) drawsGlyph(c Cell) bool {
+	if unicode.IsSpace(c.Rune) {
+		return false
+	}
+	if r.Mono == nil && c.BG.A != 0 {
+		if _, _, ok := blockShapeOf(c.Rune); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRow 输出第 i 行的背景矩形、块元素和字形，baseline 为该行字形基线的纵坐标
+func (r *SVGRenderer) writeRow(b *strings.Builder, grid *Grid, i int, baseline float64, outline bool) {
+	row := grid.Row(i)
+	top := float64(i) * grid.CellHeight
+
+	if r.Mono == nil {
+		for j := 0; j < len(row); {
+			k := j + 1
+			for k < len(row) && row[k].BG == row[j].BG {
+				k++
+			}
+			if row[j].BG.A != 0 {
+				writeRect(b, float64(j)*grid.CellWidth, top, float64(k-j)*grid.CellWidth, grid.CellHeight, row[j].BG)
+			}
+			j = k
+		}
+		for j, c := range row {
+			if c.BG.A == 0 {
+				continue
+			}
+			if shape, mask, ok := blockShapeOf(c.Rune); ok {
+				subWidth := grid.CellWidth / float64(shape.cols)
+				subHeight := grid.CellHeight / float64(shape.rows)
+				for sr := 0; sr < shape.rows; sr++ {
+					for sc := 0; sc < shape.cols; sc++ {
+						if mask&(1<<(sr*shape.cols+sc)) != 0 {
+							writeRect(b, float64(j)*grid.CellWidth+float64(sc)*subWidth, top+float64(sr)*subHeight, subWidth, subHeight, c.FG)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// 颜色相同的字符合并为一组，中间不绘制的单元格不打断分组
+	var runes []rune
+	var xs []float64
+	fill := ""
+	flush := func() {
+		if len(runes) == 0 {
+			return
+		}
+		if outline {
+			fmt.Fprintf(b, "<g fill=\"%s\">", fill)
+			for k, ch := range runes {
+				fmt.Fprintf(b, "<use xlink:href=\"#%s\" x=\"%s\" y=\"%s\"/>", glyphID(ch), svgNumber(xs[k]), svgNumber(baseline))
+			}
+			b.WriteString("</g>\n")
+		} else {
+			positions := make([]string, len(xs))
+			for k, x := range xs {
+				positions[k] = svgNumber(x)
+			}
+			fmt.Fprintf(b, "<text x=\"%s\" y=\"%s\" fill=\"%s\">%s</text>\n",
+				strings.Join(positions, " "), svgNumber(baseline), fill, svgEscape(string(runes)))
+		}
+		runes, xs = runes[:0], xs[:0]
+	}
+	for j, c := range row {
+		if !r.drawsGlyph(c) {
+			continue
+		}
+		cellFill := ""
+		if r.Mono != nil {
+			cellFill = cssColor(r.Mono)
+		} else {
+			cellFill = cssColor(c.FG)
+		}
+		if cellFill != fill {
+			flush()
+			fill = cellFill
+		}
+		runes = append(runes, c.Rune)
+		xs = append(xs, float64(j)*grid.CellWidth)
+	}
+	flush()
+}
+
+// writeGlyphDefs 将网格中用到的每个字符的轮廓作为 <path> 写入 <defs>，供 <use> 引用
+func (r *SVGRenderer) writeGlyphDefs(b *strings.Builder, f *truetype.Font, grid *Grid) {
+	used := map[rune]bool{}
+	for _, c := range grid.Cells {
+		if r.drawsGlyph(c) {
+			used[c.Rune] = true
+		}
+	}
+	list := make([]rune, 0, len(used))
+	for ch := range used {
+		list = append(list, ch)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	b.WriteString("<defs>\n")
+	scale := fixed.Int26_6(math.Round(r.Font.Size * 64))
+	var buf truetype.GlyphBuf
+	for _, ch := range list {
+		d := ""
+		if err := buf.Load(f, scale, f.Index(ch), font.HintingNone); err == nil {
+			d = glyphPath(buf.Points, buf.Ends)
+		}
+		fmt.Fprintf(b, "<path id=\"%s\" d=\"%s\"/>\n", glyphID(ch), d)
+	}
+	b.WriteString("</defs>\n")
+}
+
+// glyphPath 将 TrueType 轮廓转换为 SVG 路径，坐标以字形原点为准，y 轴向下。
+// 轮廓由二次贝塞尔曲线组成，相邻两个控制点之间隐含一个位于中点的曲线上的点。
+func glyphPath(points []truetype.Point, ends []int) string {
+	var b strings.Builder
+	onCurve := func(p truetype.Point) bool { return p.Flags&1 != 0 }
+	xy := func(p truetype.Point) string {
+		return svgNumber(float64(p.X)/64) + " " + svgNumber(-float64(p.Y)/64)
+	}
+	mid := func(p, q truetype.Point) truetype.Point {
+		return truetype.Point{X: (p.X + q.X) / 2, Y: (p.Y + q.Y) / 2, Flags: 1}
+	}
+
+	start := 0
+	for _, end := range ends {
+		contour := points[start:end]
+		start = end
+		if len(contour) == 0 {
+			continue
+		}
+
+		// 从曲线上的点开始；全部为控制点时从首尾两点的中点开始
+		first := -1
+		for k, p := range contour {
+			if onCurve(p) {
+				first = k
+				break
+			}
+		}
+		var seq []truetype.Point
+		if first < 0 {
+			seq = append([]truetype.Point{mid(contour[len(contour)-1], contour[0])}, contour...)
+		} else {
+			seq = append(append(seq, contour[first:]...), contour[:first]...)
+		}
+
+		b.WriteString("M" + xy(seq[0]))
+		var ctrl *truetype.Point
+		for _, p := range append(seq[1:], seq[0]) {
+			p := p
+			switch {
+			case onCurve(p) && ctrl == nil:
+				b.WriteString("L" + xy(p))
+			case onCurve(p):
+				b.WriteString("Q" + xy(*ctrl) + " " + xy(p))
+				ctrl = nil
+			case ctrl != nil:
+				b.WriteString("Q" + xy(*ctrl) + " " + xy(mid(*ctrl, p)))
+				ctrl = &p
+			default:
+				ctrl = &p
+			}
+		}
+		b.WriteString("Z")
+	}
+	return b.String()
+}
+
+// glyphID 返回字符轮廓在 <defs> 中的 id
+func glyphID(r rune) string {
+	return "g" + strconv.FormatInt(int64(r), 16)
+}
+
+// writeRect 输出填充矩形，crispEdges 避免相邻矩形之间出现抗锯齿缝隙
+func writeRect(b *strings.Builder, x, y, width, height float64, c color.Color) {
+	fmt.Fprintf(b, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"%s\" shape-rendering=\"crispEdges\"/>\n",
+		svgNumber(x), svgNumber(y), svgNumber(width), svgNumber(height), cssColor(c))
+}
+
+// svgNumber 将坐标保留两位小数输出
+func svgNumber(v float64) string {
+	v = math.Round(v*100) / 100
+	if v == 0 {
+		// 避免输出 -0
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// svgEscaper 转义 XML 文本和属性值中的特殊字符
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "'", "&#39;")
+
+// svgEscape 转义 XML 特殊字符
+func svgEscape(s string) string {
+	return svgEscaper.Replace(s)
+}
@@ -0,0 +1,181 @@
+package converter
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+)
+
+// svgTestFont 返回测试使用的字体
+func svgTestFont(t *testing.T) fonts.FontConfig {
+	cs, err := resolveCharset(&config.Config{Language: "english", CharMode: "simple", Scale: 1})
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	return cs.font
+}
+
+// 测试颜色相同的字符合并为一个 <text>，背景色相同的单元格合并为一个矩形
+func TestSVGRendererRuns(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	grid := NewGrid(1, 5)
+	grid.CellWidth, grid.CellHeight = 10, 20
+	grid.Width, grid.Height = 50, 20
+	grid.Cells[0] = Cell{Rune: '<', FG: red}
+	grid.Cells[1] = Cell{Rune: ' ', FG: blue}
+	grid.Cells[2] = Cell{Rune: '&', FG: red}
+	grid.Cells[3] = Cell{Rune: 'a', FG: blue, BG: red}
+	grid.Cells[4] = Cell{Rune: '▀', FG: blue, BG: red}
+
+	var b strings.Builder
+	r := &SVGRenderer{Font: svgTestFont(t), Background: color.White}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`width="50" height="20" viewBox="0 0 50 20"`,
+		`font-family: "DejaVu Sans Mono", monospace; font-weight: bold`,
+		`<rect width="100%" height="100%" fill="#ffffff"/>`,
+		`<rect x="30" y="0" width="20" height="20" fill="#ff0000"`,
+		`<rect x="40" y="0" width="10" height="10" fill="#0000ff"`,
+		`<text x="0 20" y="`,
+		`fill="#ff0000">&lt;&amp;</text>`,
+		`fill="#0000ff">a</text>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %s", want, out)
+		}
+	}
+	if strings.Count(out, "<text") != 2 {
+		t.Errorf("expected 2 text elements in %s", out)
+	}
+}
+
+// 测试 outline 模式每个字符只定义一次轮廓，并通过 <use> 引用
+func TestSVGRendererOutline(t *testing.T) {
+	grid := NewGrid(1, 3)
+	grid.CellWidth, grid.CellHeight = 10, 20
+	grid.Width, grid.Height = 30, 20
+	for i := range grid.Cells {
+		grid.Cells[i] = Cell{Rune: '#'}
+	}
+
+	var b strings.Builder
+	r := &SVGRenderer{Font: svgTestFont(t), FontMode: SVGFontOutline, Mono: color.White, Background: color.Transparent}
+	if err := r.Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.String()
+	if strings.Count(out, `<path id="g23"`) != 1 || strings.Count(out, `xlink:href="#g23"`) != 3 {
+		t.Errorf("expected one glyph definition used three times in %s", out)
+	}
+	if strings.Contains(out, "<text") || strings.Contains(out, "<rect") {
+		t.Errorf("expected no text or background in %s", out)
+	}
+
+	r.FontMode = "bitmap"
+	if err := r.Render(context.Background(), &b, grid); err == nil {
+		t.Error("expected error for unsupported font mode")
+	}
+}
+
+// 测试转换得到的字形路径填充后与字体渲染的结果一致
+func TestGlyphPathMatchesRaster(t *testing.T) {
+	cfg := svgTestFont(t)
+	f, err := fonts.LoadTrueType(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := fonts.LoadFace(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const size, originX, originY = 40, 10.0, 30.0
+	for _, r := range "@gQ%" {
+		var buf truetype.GlyphBuf
+		if err := buf.Load(f, fixed.Int26_6(cfg.Size*64), f.Index(r), font.HintingNone); err != nil {
+			t.Fatal(err)
+		}
+
+		text := gg.NewContext(size, size)
+		text.SetFontFace(face)
+		text.SetColor(color.White)
+		text.DrawString(string(r), originX, originY)
+
+		path := gg.NewContext(size, size)
+		fillSVGPath(path, glyphPath(buf.Points, buf.Ends), originX, originY)
+		path.SetColor(color.White)
+		path.SetFillRuleWinding()
+		path.Fill()
+
+		if diff := alphaDiff(text.Image(), path.Image()); diff > 0.01 {
+			t.Errorf("%q: outline differs from rendered glyph by %.3f", r, diff)
+		}
+	}
+}
+
+// fillSVGPath 在 dc 中按 (dx, dy) 平移后重建 glyphPath 输出的路径
+func fillSVGPath(dc *gg.Context, d string, dx, dy float64) {
+	fields := strings.FieldsFunc(d, func(r rune) bool { return r == ' ' })
+	var tokens []string
+	for _, field := range fields {
+		for len(field) > 0 {
+			i := strings.IndexAny(field[1:], "MLQZ") + 1
+			if i == 0 {
+				i = len(field)
+			}
+			tokens = append(tokens, field[:i])
+			field = field[i:]
+		}
+	}
+	num := func(i int) float64 {
+		v, _ := strconv.ParseFloat(strings.TrimLeft(tokens[i], "MLQZ"), 64)
+		return v
+	}
+	for i := 0; i < len(tokens); {
+		switch tokens[i][0] {
+		case 'M':
+			dc.MoveTo(num(i)+dx, num(i+1)+dy)
+			i += 2
+		case 'L':
+			dc.LineTo(num(i)+dx, num(i+1)+dy)
+			i += 2
+		case 'Q':
+			dc.QuadraticTo(num(i)+dx, num(i+1)+dy, num(i+2)+dx, num(i+3)+dy)
+			i += 4
+		case 'Z':
+			dc.ClosePath()
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+// alphaDiff 返回两幅图像 alpha 通道的平均差异，范围 [0, 1]
+func alphaDiff(a, b image.Image) float64 {
+	var sum float64
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, _, aa := a.At(x, y).RGBA()
+			_, _, _, ba := b.At(x, y).RGBA()
+			sum += float64(absDiff(aa, ba)) / 0xffff
+		}
+	}
+	return sum / float64(bounds.Dx()*bounds.Dy())
+}
@@ -0,0 +1,509 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// TextOptions 解析文本的参数
+type TextOptions struct {
+	// CP437 为 true 时按代码页 437 解码。带 SAUCE 记录或不是合法 UTF-8 的文本总是按 CP437 解码
+	CP437 bool
+	// Foreground、Background 终端的默认前景色和背景色，UTF-8 文本未设置颜色的字符使用 Foreground，
+	// 反显时没有背景色的单元格使用 Background 作为前景色
+	Foreground, Background color.RGBA
+}
+
+// ParseText 将纯文本、ANSI 彩色文本或 .ans 文件解析为字符网格，解释 SGR 颜色和光标移动序列。
+// 没有背景色的单元格 BG 为零值。CP437 文本按 SAUCE 记录中的宽度换行，没有记录时为 80 列，
+// 其中的颜色使用 VGA 调色板，粗体表示高亮前景色，闪烁表示高亮背景色（iCE 颜色）；
+// 其他文本不自动换行，宽度为最长的一行。网格的单元格尺寸为 0，由调用方按字体设置。
+// 光标位置不超过 maxGridSide，网格超过 maxGridCells 个单元格时返回错误。
+func ParseText(data []byte, opts TextOptions) (*Grid, error) {
+	width := 0
+	if body, sauceWidth, ok := stripSAUCE(data); ok {
+		data, width, opts.CP437 = body, sauceWidth, true
+	}
+	if !opts.CP437 && !utf8.Valid(data) {
+		opts.CP437 = true
+	}
+
+	var runes []rune
+	if opts.CP437 {
+		runes = decodeCP437(data)
+		if width == 0 {
+			width = 80
+		}
+	} else {
+		runes = []rune(string(data))
+	}
+	t := newTextTerminal(opts, width)
+	t.run(runes)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.grid()
+}
+
+// stripSAUCE 去掉文件末尾的 SAUCE 记录，字符类型的记录同时返回其中的宽度
+func stripSAUCE(data []byte) (body []byte, width int, ok bool) {
+	if len(data) < 128 {
+		return data, 0, false
+	}
+	record := data[len(data)-128:]
+	if !bytes.HasPrefix(record, []byte("SAUCE00")) {
+		return data, 0, false
+	}
+	if record[94] == sauceCharacter {
+		width = int(binary.LittleEndian.Uint16(record[96:98]))
+	}
+	return data[:len(data)-128], width, true
+}
+
+// 终端颜色状态中的特殊值，其他值为 256 色调色板的索引
+const (
+	colorDefault = -1
+	colorRGB     = -2
+)
+
+// textTerminal 解释文本中的控制字符和 ANSI 转义序列，将字符写入按需增长的网格
+type textTerminal struct {
+	opts    TextOptions
+	palette []color.RGBA
+	// width 大于 0 时在该列换行
+	width int
+	rows  [][]Cell
+	// row、col 光标位置，wrap 表示已写满一行，下一个字符写在下一行开头
+	row, col           int
+	wrap               bool
+	savedRow, savedCol int
+	// fg、bg 当前颜色，为 colorRGB 时使用 fgRGB、bgRGB
+	fg, bg               int
+	fgRGB, bgRGB         color.RGBA
+	bold, blink, reverse bool
+	// cells 已分配的单元格数，err 为超出上限时的错误，之后的字符不再处理
+	cells int
+	err   error
+}
+
+// newTextTerminal 创建终端，CP437 文本的前 16 色使用 VGA 调色板，其他文本使用 xterm 调色板
+func newTextTerminal(opts TextOptions, width int) *textTerminal {
+	palette := xtermColors()
+	if opts.CP437 {
+		copy(palette, vgaPalette.colors)
+	}
+	return &textTerminal{opts: opts, palette: palette, width: width, fg: colorDefault, bg: colorDefault}
+}
+
+// run 依次处理字符，遇到 EOF 字符（0x1A）时停止
+func (t *textTerminal) run(runes []rune) {
+	for i := 0; i < len(runes) && t.err == nil; i++ {
+		switch r := runes[i]; r {
+		case 0x1A:
+			return
+		case 0x1B:
+			i = t.escape(runes, i)
+		case '\r':
+			t.col, t.wrap = 0, false
+		case '\n':
+			t.row, t.col, t.wrap = t.row+1, 0, false
+		case '\t':
+			t.moveTo(t.row, (t.col/8+1)*8)
+		case '\b':
+			t.moveTo(t.row, t.col-1)
+		default:
+			if r >= 0x20 && r != 0x7F && !(r >= 0x80 && r < 0xA0) {
+				t.put(r)
+			}
+		}
+	}
+}
+
+// escape 处理从 runes[i] 开始的转义序列，返回序列最后一个字符的下标
+func (t *textTerminal) escape(runes []rune, i int) int {
+	if i+1 >= len(runes) {
+		return i
+	}
+	switch runes[i+1] {
+	case '[':
+		// CSI：参数字节之后是 0x40-0x7E 之间的结束字节
+		for j := i + 2; j < len(runes); j++ {
+			if runes[j] >= 0x40 && runes[j] <= 0x7E {
+				t.csi(string(runes[i+2:j]), runes[j])
+				return j
+			}
+		}
+		return len(runes) - 1
+	case ']':
+		// OSC：以 BEL 或 ESC \ 结束，内容忽略
+		for j := i + 2; j < len(runes); j++ {
+			if runes[j] == 0x07 {
+				return j
+			}
+			if runes[j] == 0x1B && j+1 < len(runes) && runes[j+1] == '\\' {
+				return j + 1
+			}
+		}
+		return len(runes) - 1
+	default:
+		return i + 1
+	}
+}
+
+// csi 执行 CSI 序列，只处理颜色、光标移动和清除，其他序列忽略
+func (t *textTerminal) csi(params string, final rune) {
+	if strings.HasPrefix(params, "?") {
+		return
+	}
+	var args []int
+	if params != "" {
+		for _, p := range strings.Split(params, ";") {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				n = -1
+			}
+			args = append(args, n)
+		}
+	}
+	// arg 返回第 i 个参数，缺省或为 0 时返回 def
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'm':
+		t.sgr(args)
+	case 'A':
+		t.moveTo(t.row-arg(0, 1), t.col)
+	case 'B':
+		t.moveTo(t.row+arg(0, 1), t.col)
+	case 'C':
+		t.moveTo(t.row, t.col+arg(0, 1))
+	case 'D':
+		t.moveTo(t.row, t.col-arg(0, 1))
+	case 'H', 'f':
+		t.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'J':
+		if arg(0, 0) == 2 {
+			t.rows = nil
+			t.moveTo(0, 0)
+		}
+	case 'K':
+		t.eraseLine(arg(0, 0))
+	case 's':
+		t.savedRow, t.savedCol = t.row, t.col
+	case 'u':
+		t.moveTo(t.savedRow, t.savedCol)
+	}
+}
+
+// sgr 按 SGR 参数设置颜色和属性
+func (t *textTerminal) sgr(args []int) {
+	if len(args) == 0 {
+		args = []int{0}
+	}
+	for i := 0; i < len(args); i++ {
+		switch p := args[i]; {
+		case p == 0:
+			t.fg, t.bg = colorDefault, colorDefault
+			t.bold, t.blink, t.reverse = false, false, false
+		case p == 1:
+			t.bold = true
+		case p == 5 || p == 6:
+			t.blink = true
+		case p == 7:
+			t.reverse = true
+		case p == 22:
+			t.bold = false
+		case p == 25:
+			t.blink = false
+		case p == 27:
+			t.reverse = false
+		case p >= 30 && p <= 37:
+			t.fg = p - 30
+		case p == 39:
+			t.fg = colorDefault
+		case p >= 40 && p <= 47:
+			t.bg = p - 40
+		case p == 49:
+			t.bg = colorDefault
+		case p >= 90 && p <= 97:
+			t.fg = p - 90 + 8
+		case p >= 100 && p <= 107:
+			t.bg = p - 100 + 8
+		case p == 38 || p == 48:
+			index, rgb, n := extendedColor(args[i+1:])
+			i += n
+			if p == 38 {
+				t.fg, t.fgRGB = index, rgb
+			} else {
+				t.bg, t.bgRGB = index, rgb
+			}
+		}
+	}
+}
+
+// extendedColor 解析 38/48 之后的 5;n 或 2;r;g;b 参数，返回颜色和使用的参数个数
+func extendedColor(args []int) (index int, rgb color.RGBA, n int) {
+	clamp := func(v int) int {
+		return int(math.Max(0, math.Min(255, float64(v))))
+	}
+	switch {
+	case len(args) >= 2 && args[0] == 5:
+		return clamp(args[1]), rgb, 2
+	case len(args) >= 4 && args[0] == 2:
+		return colorRGB, color.RGBA{uint8(clamp(args[1])), uint8(clamp(args[2])), uint8(clamp(args[3])), 255}, 4
+	default:
+		return colorDefault, rgb, len(args)
+	}
+}
+
+// moveTo 移动光标，坐标不小于 0 且小于 maxGridSide，有宽度时列不超过最后一列
+func (t *textTerminal) moveTo(row, col int) {
+	row = clampInt(row, 0, maxGridSide-1)
+	col = clampInt(col, 0, maxGridSide-1)
+	if t.width > 0 && col >= t.width {
+		col = t.width - 1
+	}
+	t.row, t.col, t.wrap = row, col, false
+}
+
+// put 以当前颜色在光标处写入字符并右移光标，写满一行后在下一个字符前换行
+func (t *textTerminal) put(r rune) {
+	if t.wrap {
+		t.row, t.col, t.wrap = t.row+1, 0, false
+	}
+	grow := maxInt(t.col+1-t.rowLen(t.row), 0)
+	if t.row >= maxGridSide || t.col >= maxGridSide || t.cells+grow > maxGridCells {
+		t.err = fmt.Errorf("text too large: more than %d rows, %d columns or %d cells", maxGridSide, maxGridSide, maxGridCells)
+		return
+	}
+	t.cells += grow
+	for len(t.rows) <= t.row {
+		t.rows = append(t.rows, nil)
+	}
+	for len(t.rows[t.row]) <= t.col {
+		t.rows[t.row] = append(t.rows[t.row], t.blank())
+	}
+	fg, bg := t.colors()
+	t.rows[t.row][t.col] = Cell{Rune: r, FG: fg, BG: bg}
+	if t.width > 0 && t.col == t.width-1 {
+		t.wrap = true
+	} else {
+		t.col++
+	}
+}
+
+// rowLen 返回第 row 行已分配的单元格数
+func (t *textTerminal) rowLen(row int) int {
+	if row < len(t.rows) {
+		return len(t.rows[row])
+	}
+	return 0
+}
+
+// eraseLine 清除当前行：mode 为 0 时清除光标到行尾，为 1 时清除行首到光标，为 2 时清除整行
+func (t *textTerminal) eraseLine(mode int) {
+	if t.row >= len(t.rows) {
+		return
+	}
+	row := t.rows[t.row]
+	switch mode {
+	case 0:
+		if t.col < len(row) {
+			t.rows[t.row] = row[:t.col]
+		}
+	case 1:
+		for j := 0; j <= t.col && j < len(row); j++ {
+			row[j] = t.blank()
+		}
+	case 2:
+		t.rows[t.row] = nil
+	}
+}
+
+// blank 返回没有写入字符的单元格
+func (t *textTerminal) blank() Cell {
+	return Cell{Rune: ' ', FG: t.opts.Foreground}
+}
+
+// colors 返回当前的前景色和背景色，背景为默认颜色时 BG 为零值
+func (t *textTerminal) colors() (fg, bg color.RGBA) {
+	switch {
+	case t.fg == colorRGB:
+		fg = t.fgRGB
+	case t.fg == colorDefault && !t.opts.CP437:
+		fg = t.opts.Foreground
+	default:
+		index := t.fg
+		if index == colorDefault {
+			index = 7
+		}
+		if t.bold && index < 8 {
+			index += 8
+		}
+		fg = t.palette[index]
+	}
+
+	switch {
+	case t.bg == colorRGB:
+		bg = t.bgRGB
+	case t.bg == colorDefault && !(t.opts.CP437 && t.blink):
+	default:
+		index := t.bg
+		if index == colorDefault {
+			index = 0
+		}
+		if t.opts.CP437 && t.blink && index < 8 {
+			index += 8
+		}
+		bg = t.palette[index]
+	}
+
+	if t.reverse {
+		if bg.A == 0 {
+			bg = t.opts.Background
+		}
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// grid 返回写入的字符组成的网格，较短的行以空白单元格补齐，补齐后超出上限时返回错误
+func (t *textTerminal) grid() (*Grid, error) {
+	cols := t.width
+	if cols == 0 {
+		for _, row := range t.rows {
+			if len(row) > cols {
+				cols = len(row)
+			}
+		}
+	}
+	if len(t.rows) > 0 && cols > 0 {
+		if err := checkGridSize(len(t.rows), cols); err != nil {
+			return nil, fmt.Errorf("text too large: %v", err)
+		}
+	}
+	grid := NewGrid(len(t.rows), cols)
+	for i, row := range t.rows {
+		for j := 0; j < cols; j++ {
+			if j < len(row) {
+				*grid.At(i, j) = row[j]
+			} else {
+				*grid.At(i, j) = t.blank()
+			}
+		}
+	}
+	return grid, nil
+}
+
+// ParseText 按配置的背景色和字体解析文本：默认前景色与背景相反，
+// 单元格尺寸为字体中容纳所有字符的尺寸，配置了 CellWidth、CellHeight 时使用配置的尺寸
+func (p *Pipeline) ParseText(data []byte, cp437 bool) (*Grid, error) {
+	grid, err := ParseText(data, p.textOptions(cp437))
+	if err != nil {
+		return nil, err
+	}
+	if grid.Rows == 0 || grid.Cols == 0 {
+		return nil, fmt.Errorf("no text to render in input")
+	}
+	if err := p.sizeCells(grid); err != nil {
+		return nil, err
+	}
+	return grid, nil
+}
+
+// textOptions 返回与配置的背景色相反的默认前景色
+func (p *Pipeline) textOptions(cp437 bool) TextOptions {
+	opts := TextOptions{CP437: cp437, Foreground: color.RGBA{255, 255, 255, 255}, Background: color.RGBA{0, 0, 0, 255}}
+	if p.cfg.Background == "white" {
+		opts.Foreground, opts.Background = opts.Background, opts.Foreground
+	}
+	return opts
+}
+
+// sizeCells 为网格设置相同的单元格尺寸和图像尺寸，单元格尺寸容纳所有网格中的字符
+func (p *Pipeline) sizeCells(grids ...*Grid) error {
+	width, height := float64(p.cfg.CellWidth), float64(p.cfg.CellHeight)
+	if width <= 0 || height <= 0 {
+		face, err := fonts.LoadFace(p.cs.font)
+		if err != nil {
+			return err
+		}
+		chars := []rune{'M'}
+		seen := map[rune]bool{}
+		for _, grid := range grids {
+			for _, c := range grid.Cells {
+				if !seen[c.Rune] {
+					seen[c.Rune] = true
+					chars = append(chars, c.Rune)
+				}
+			}
+		}
+		w, h := fonts.CellSize(face, chars)
+		if width <= 0 {
+			width = float64(w)
+		}
+		if height <= 0 {
+			height = float64(h)
+		}
+	}
+	for _, grid := range grids {
+		grid.CellWidth, grid.CellHeight = width, height
+		grid.Width = int(math.Ceil(float64(grid.Cols) * width))
+		grid.Height = int(math.Ceil(float64(grid.Rows) * height))
+	}
+	return nil
+}
+
+// TextToImage 将 cfg.InputPath 中的纯文本、ANSI 彩色文本或 .ans 文件用配置的字体渲染到 cfg.OutputPath。
+// 输出格式由 cfg.Format 指定，否则按输出文件的扩展名识别，默认为 PNG；扩展名为 .ans 的输入按 CP437 解码。
+func TextToImage(cfg *config.Config) error {
+	return TextToImageContext(context.Background(), cfg)
+}
+
+// TextToImageContext 与 TextToImage 相同，ctx 取消时停止渲染，不会留下不完整的输出文件
+func TextToImageContext(ctx context.Context, cfg *config.Config) error {
+	format, err := resolveFormat(cfg.Format, cfg.OutputPath, "png")
+	if err != nil {
+		return err
+	}
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	tracker := progress.NewTracker(ctx, progress.StageDecode, 1)
+	data, err := os.ReadFile(cfg.InputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %v", err)
+	}
+	grid, err := p.ParseText(data, strings.EqualFold(filepath.Ext(cfg.InputPath), ".ans"))
+	if err != nil {
+		return err
+	}
+	tracker.Finish()
+
+	renderer, err := NewRenderer(format, p.RenderOptions(false))
+	if err != nil {
+		return err
+	}
+	return renderFile(progress.WithStage(ctx, progress.StageDraw), cfg.OutputPath, renderer, grid)
+}
@@ -0,0 +1,168 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	textWhite = color.RGBA{255, 255, 255, 255}
+	textBlack = color.RGBA{0, 0, 0, 255}
+)
+
+// 测试解析 UTF-8 文本中的 SGR 颜色：16 色、粗体、256 色、真彩色和反显
+func TestParseTextSGR(t *testing.T) {
+	data := "a\x1b[31mb\x1b[1mc\x1b[0m\n\x1b[48;2;1;2;3mé\x1b[38;5;196md\x1b[0;7me\x1b]0;title\x07"
+	grid, err := ParseText([]byte(data), TextOptions{Foreground: textWhite, Background: textBlack})
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+	if grid.Rows != 2 || grid.Cols != 3 {
+		t.Fatalf("expected 2x3 grid, got %dx%d", grid.Rows, grid.Cols)
+	}
+	xterm := xtermColors()
+	tests := []struct {
+		cell Cell
+		want Cell
+	}{
+		{*grid.At(0, 0), Cell{Rune: 'a', FG: textWhite}},
+		{*grid.At(0, 1), Cell{Rune: 'b', FG: xterm[1]}},
+		{*grid.At(0, 2), Cell{Rune: 'c', FG: xterm[9]}},
+		{*grid.At(1, 0), Cell{Rune: 'é', FG: textWhite, BG: color.RGBA{1, 2, 3, 255}}},
+		{*grid.At(1, 1), Cell{Rune: 'd', FG: xterm[196], BG: color.RGBA{1, 2, 3, 255}}},
+		{*grid.At(1, 2), Cell{Rune: 'e', FG: textBlack, BG: textWhite}},
+	}
+	for i, tt := range tests {
+		if tt.cell != tt.want {
+			t.Errorf("cell %d: expected %+v, got %+v", i, tt.want, tt.cell)
+		}
+	}
+}
+
+// 测试光标移动、制表符和清除序列
+func TestParseTextCursor(t *testing.T) {
+	data := "ab\x1b[2Cc\tx\r\nzzzz\x1b[2D\x1b[Kq\x1b[2D\x1b[1K\x1b[3;2Hy\x1b[s\x1b[Hw\x1b[uv"
+	grid, err := ParseText([]byte(data), TextOptions{Foreground: textWhite})
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+	expected := []string{"wb  c   x", "  q      ", " yv      "}
+	if grid.Rows != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), grid.Rows)
+	}
+	for i, want := range expected {
+		var row []rune
+		for _, c := range grid.Row(i) {
+			row = append(row, c.Rune)
+		}
+		if string(row) != want {
+			t.Errorf("row %d: expected %q, got %q", i, want, string(row))
+		}
+	}
+}
+
+// 测试 .ans 输出解析后得到相同的 CP437 字符和 VGA 颜色，整行按 SAUCE 宽度自动换行
+func TestParseTextANSRoundTrip(t *testing.T) {
+	vga := vgaPalette.colors
+	grid := NewGrid(2, 3)
+	grid.Cells[0] = Cell{Rune: '░', FG: vga[9], BG: vga[12]}
+	grid.Cells[1] = Cell{Rune: 'é', FG: vga[2]}
+	grid.Cells[2] = Cell{Rune: '█', FG: vga[15]}
+	grid.Cells[3] = Cell{Rune: 'x', FG: vga[7]}
+	grid.Cells[4] = Cell{Rune: ' ', FG: vga[7]}
+	grid.Cells[5] = Cell{Rune: ' ', FG: vga[7]}
+
+	var b bytes.Buffer
+	if err := (&ANSArtRenderer{}).Render(context.Background(), &b, grid); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	parsed, err := ParseText(b.Bytes(), TextOptions{})
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+	if parsed.Rows != 2 || parsed.Cols != 3 {
+		t.Fatalf("expected 2x3 grid, got %dx%d", parsed.Rows, parsed.Cols)
+	}
+	for i, want := range grid.Cells {
+		got := parsed.Cells[i]
+		if got.Rune != want.Rune || got.BG != want.BG || (want.Rune != ' ' && got.FG != want.FG) {
+			t.Errorf("cell %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+// 测试超大的光标位置被限制在网格上限内，补齐后超出单元格上限的文本返回错误而不会耗尽内存
+func TestParseTextHugeCursor(t *testing.T) {
+	if _, err := ParseText([]byte("\x1b[99999999;99999999Hx"), TextOptions{}); err == nil || !strings.Contains(err.Error(), "text too large") {
+		t.Errorf("expected text too large error, got %v", err)
+	}
+
+	tests := []struct {
+		data       string
+		opts       TextOptions
+		rows, cols int
+	}{
+		{"\x1b[99999999Bx", TextOptions{}, maxGridSide, 1},
+		{"\x1b[99999999Cx", TextOptions{}, 1, maxGridSide},
+		// CP437 文本有固定宽度，列被限制在最后一列
+		{"\x1b[1;99999999Hx", TextOptions{CP437: true}, 1, 80},
+	}
+	for _, tt := range tests {
+		grid, err := ParseText([]byte(tt.data), tt.opts)
+		if err != nil {
+			t.Fatalf("%q: ParseText failed: %v", tt.data, err)
+		}
+		if grid.Rows != tt.rows || grid.Cols != tt.cols || grid.At(tt.rows-1, tt.cols-1).Rune != 'x' {
+			t.Errorf("%q: expected x in the last cell of a %dx%d grid, got %dx%d", tt.data, tt.cols, tt.rows, grid.Cols, grid.Rows)
+		}
+	}
+}
+
+// 测试 CP437 控制字节之外的低位字节解码为图形字符
+func TestDecodeCP437(t *testing.T) {
+	got := string(decodeCP437([]byte{0x01, 0x1F, 0x7F, 0x0D, 0x1B, 0xB0, 0xFF}))
+	if got != "☺▼⌂\r\x1b░ " {
+		t.Errorf("unexpected decoding %q", got)
+	}
+}
+
+// 测试 text2image 按单元格尺寸和留白输出图像
+func TestTextToImage(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputPath, []byte("ab\n\x1b[31mcde\x1b[0m\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	cfg := MockConfig(inputPath, filepath.Join(dir, "output.png"), 10, 1, "simple", "black")
+	cfg.CellWidth, cfg.CellHeight, cfg.Padding = 10, 20, 5
+	if err := TextToImage(cfg); err != nil {
+		t.Fatalf("TextToImage failed: %v", err)
+	}
+
+	file, err := os.Open(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 3*10+2*5 || img.Bounds().Dy() != 2*20+2*5 {
+		t.Errorf("expected 40x50 image, got %v", img.Bounds())
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("expected black padding, got %v", img.At(0, 0))
+	}
+
+	os.WriteFile(inputPath, []byte("\n\n"), 0644)
+	if err := TextToImage(cfg); err == nil {
+		t.Error("expected error for input without text")
+	}
+}
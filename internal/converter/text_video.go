@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+	"github.com/hai119/Go-ASCII-generator/internal/utils"
+)
+
+// DefaultTextVideoFPS 未配置帧率时 text2video 使用的帧率，与 video2text 提取帧的帧率相同
+const DefaultTextVideoFPS = frameRate
+
+// frameHeader 匹配 video2text 输出中每一帧开头的 "Frame N:" 行
+var frameHeader = regexp.MustCompile(`(?m)^Frame \d+:\r?\n`)
+
+// SplitTextFrames 将 video2text 的输出按 "Frame N:" 行拆分为各帧的文本。
+// 每帧末尾用于分隔的空行被去掉；没有帧标记时整个输入作为一帧。
+func SplitTextFrames(data []byte) [][]byte {
+	headers := frameHeader.FindAllIndex(data, -1)
+	if len(headers) == 0 {
+		return [][]byte{data}
+	}
+	frames := make([][]byte, len(headers))
+	for i, h := range headers {
+		end := len(data)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		frame := data[h[1]:end]
+		if bytes.HasSuffix(frame, []byte("\r\n\r\n")) {
+			frame = frame[:len(frame)-2]
+		} else if bytes.HasSuffix(frame, []byte("\n\n")) {
+			frame = frame[:len(frame)-1]
+		}
+		frames[i] = frame
+	}
+	return frames
+}
+
+// ParseTextFrames 解析 video2text 输出中的每一帧。
+// 所有帧补齐为相同的行列数并使用相同的单元格尺寸，渲染出的图像尺寸一致。
+func (p *Pipeline) ParseTextFrames(data []byte, cp437 bool) ([]*Grid, error) {
+	opts := p.textOptions(cp437)
+	var grids []*Grid
+	rows, cols := 0, 0
+	for _, frame := range SplitTextFrames(data) {
+		grid, err := ParseText(frame, opts)
+		if err != nil {
+			return nil, err
+		}
+		if grid.Rows > rows {
+			rows = grid.Rows
+		}
+		if grid.Cols > cols {
+			cols = grid.Cols
+		}
+		grids = append(grids, grid)
+	}
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("no text to render in input")
+	}
+
+	blank := Cell{Rune: ' ', FG: opts.Foreground}
+	for i, grid := range grids {
+		if grid.Rows == rows && grid.Cols == cols {
+			continue
+		}
+		padded := NewGrid(rows, cols)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				if r < grid.Rows && c < grid.Cols {
+					*padded.At(r, c) = *grid.At(r, c)
+				} else {
+					*padded.At(r, c) = blank
+				}
+			}
+		}
+		grids[i] = padded
+	}
+	if err := p.sizeCells(grids...); err != nil {
+		return nil, err
+	}
+	return grids, nil
+}
+
+// TextToVideo 将 cfg.InputPath 中 video2text 输出的各帧用配置的字体和颜色渲染，编码为 cfg.OutputPath 的视频。
+// 输出格式由 cfg.Format 指定，否则按输出文件的扩展名识别：gif 直接编码为动画 GIF，mp4 使用 ffmpeg 编码。
+// 文本中不包含帧率，使用 cfg.FPS，未配置时为 DefaultTextVideoFPS。
+func TextToVideo(cfg *config.Config) error {
+	return TextToVideoContext(context.Background(), cfg)
+}
+
+// TextToVideoContext 与 TextToVideo 相同，ctx 取消时停止渲染并终止 ffmpeg，不会留下不完整的输出视频
+func TextToVideoContext(ctx context.Context, cfg *config.Config) error {
+	if err := checkVideoOutput(cfg.OutputPath); err != nil {
+		return err
+	}
+	format := strings.ToLower(cfg.Format)
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(cfg.OutputPath), "."))
+	}
+	if format == "" {
+		format = "mp4"
+	}
+	if format != "gif" && format != "mp4" {
+		return fmt.Errorf("unsupported video format: %s", format)
+	}
+	fps := cfg.FPS
+	if fps <= 0 {
+		fps = DefaultTextVideoFPS
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	tracker := progress.NewTracker(ctx, progress.StageDecode, 1)
+	data, err := os.ReadFile(cfg.InputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %v", err)
+	}
+	grids, err := p.ParseTextFrames(data, strings.EqualFold(filepath.Ext(cfg.InputPath), ".ans"))
+	if err != nil {
+		return err
+	}
+	tracker.Finish()
+
+	output, err := utils.CreateAtomic(cfg.OutputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer output.Close()
+
+	var encoder interface {
+		WriteFrame(img image.Image) error
+		Close() error
+		Abort()
+	}
+	if format == "gif" {
+		encoder = NewGIFEncoder(output, fps)
+	} else {
+		encoder = NewVideoEncoder(ctx, output, fps, format)
+	}
+
+	renderer := p.ImageRenderer(false)
+	renderer.Padding = cfg.Padding
+	frames := progress.NewTracker(ctx, progress.StageFrames, len(grids))
+	for _, grid := range grids {
+		err := ctx.Err()
+		var img image.Image
+		if err == nil {
+			img, err = renderer.Draw(ctx, grid)
+		}
+		if err == nil {
+			err = encoder.WriteFrame(img)
+		}
+		if err != nil {
+			encoder.Abort()
+			return err
+		}
+		frames.Add(1)
+	}
+
+	encoding := progress.NewTracker(ctx, progress.StageEncode, 0)
+	stopWatch := encoding.WatchFile(output.Name(), 500*time.Millisecond)
+	err = encoder.Close()
+	stopWatch()
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(output.Name()); err == nil {
+		encoding.SetBytes(info.Size())
+	}
+	encoding.Finish()
+
+	return output.Commit()
+}
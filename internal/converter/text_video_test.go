@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试按 "Frame N:" 行拆分 video2text 的输出并去掉帧之间的空行
+func TestSplitTextFrames(t *testing.T) {
+	data := "Frame 0:\nab\ncd\n\nFrame 1:\r\n\x1b[31mef\x1b[0m\r\n\r\nFrame 2:\ngh\n"
+	frames := SplitTextFrames([]byte(data))
+	want := []string{"ab\ncd\n", "\x1b[31mef\x1b[0m\r\n", "gh\n"}
+	if len(frames) != len(want) {
+		t.Fatalf("expected %d frames, got %d", len(want), len(frames))
+	}
+	for i, frame := range frames {
+		if string(frame) != want[i] {
+			t.Errorf("frame %d: expected %q, got %q", i, want[i], frame)
+		}
+	}
+
+	if frames := SplitTextFrames([]byte("plain\n")); len(frames) != 1 || string(frames[0]) != "plain\n" {
+		t.Errorf("expected input without headers as one frame, got %q", frames)
+	}
+}
+
+// 测试不同尺寸的帧补齐为相同的网格尺寸
+func TestParseTextFrames(t *testing.T) {
+	cfg := MockConfig("", "", 10, 1, "simple", "black")
+	cfg.CellWidth, cfg.CellHeight = 8, 16
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	defer p.Close()
+
+	grids, err := p.ParseTextFrames([]byte("Frame 0:\na\n\nFrame 1:\nbcd\nef\n\n"), false)
+	if err != nil {
+		t.Fatalf("ParseTextFrames failed: %v", err)
+	}
+	if len(grids) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(grids))
+	}
+	for i, grid := range grids {
+		if grid.Rows != 2 || grid.Cols != 3 || grid.Width != 24 || grid.Height != 32 {
+			t.Errorf("frame %d: expected 3x2 grid of 24x32 pixels, got %dx%d of %dx%d", i, grid.Cols, grid.Rows, grid.Width, grid.Height)
+		}
+	}
+	if c := grids[0].At(1, 2); c.Rune != ' ' || c.FG != textWhite {
+		t.Errorf("expected padded cell to be a blank with the default foreground, got %+v", c)
+	}
+
+	if _, err := p.ParseTextFrames([]byte("Frame 0:\n\n"), false); err == nil {
+		t.Error("expected error for input without text")
+	}
+}
+
+// 测试输出动画 GIF：帧数、尺寸和按帧率计算的延迟
+func TestTextToVideoGIF(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "frames.txt")
+	data := "Frame 0:\nab\n\nFrame 1:\n\x1b[32mcd\x1b[0m\n\nFrame 2:\nef\n\n"
+	if err := os.WriteFile(inputPath, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	cfg := MockConfig(inputPath, filepath.Join(dir, "output.gif"), 10, 1, "simple", "black")
+	cfg.CellWidth, cfg.CellHeight, cfg.FPS = 10, 20, 30
+	if err := TextToVideo(cfg); err != nil {
+		t.Fatalf("TextToVideo failed: %v", err)
+	}
+
+	file, err := os.Open(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Fatalf("expected 3 frames, got %d", len(anim.Image))
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+		t.Errorf("expected 20x20 frames, got %v", b)
+	}
+	// 30 帧每秒时延迟依次为 3、4、3 百分之一秒，总时长为 0.1 秒
+	total := 0
+	for _, delay := range anim.Delay {
+		total += delay
+	}
+	if total != 10 {
+		t.Errorf("expected total delay of 10, got %v", anim.Delay)
+	}
+
+	cfg.OutputPath = filepath.Join(dir, "output.webm")
+	if err := TextToVideo(cfg); err == nil {
+		t.Error("expected error for unsupported video format")
+	}
+}
@@ -0,0 +1,252 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 色调映射的默认参数
+const (
+	histogramBins    = 256
+	claheTiles       = 8
+	defaultClipLimit = 2.0
+	autoLevelsClip   = 0.005
+)
+
+// toneMap 采样与字符选择之间的亮度色调映射阶段
+// 依次执行：自动色阶、黑白场、直方图均衡/CLAHE、亮度对比度、伽马、反转。
+type toneMap struct {
+	gamma      float64
+	brightness float64
+	contrast   float64
+	blackPoint float64
+	whitePoint float64
+	autoLevels bool
+	equalize   string
+	clipLimit  float64
+	invert     bool
+}
+
+// newToneMap 根据配置创建色调映射，零值参数使用不改变亮度的默认值
+// Invert 为 "auto"（或为空）时只在浅色（白色）背景上反转，深色背景保持原有的字符梯度方向。
+func newToneMap(cfg *config.Config) (*toneMap, error) {
+	tone := cfg.Tone
+	t := &toneMap{
+		gamma:      tone.Gamma,
+		brightness: tone.Brightness,
+		contrast:   tone.Contrast,
+		blackPoint: tone.BlackPoint,
+		whitePoint: tone.WhitePoint,
+		autoLevels: tone.AutoLevels,
+		equalize:   tone.Equalize,
+		clipLimit:  tone.ClaheClip,
+	}
+	if t.gamma <= 0 {
+		t.gamma = 1
+	}
+	if t.contrast <= 0 {
+		t.contrast = 1
+	}
+	if t.whitePoint <= t.blackPoint {
+		t.whitePoint = 1
+	}
+	if t.clipLimit <= 0 {
+		t.clipLimit = defaultClipLimit
+	}
+
+	switch t.equalize {
+	case "", "none", "histogram", "clahe":
+	default:
+		return nil, fmt.Errorf("unsupported equalization: %s", t.equalize)
+	}
+
+	switch tone.Invert {
+	case "", "auto":
+		t.invert = cfg.Background == "white"
+	case "true":
+		t.invert = true
+	case "false":
+	default:
+		return nil, fmt.Errorf("unsupported invert mode: %s", tone.Invert)
+	}
+	return t, nil
+}
+
+// apply 对 width x height 的亮度网格就地执行色调映射
+func (t *toneMap) apply(values []float64, width, height int) {
+	if t.autoLevels {
+		lo, hi := percentiles(values, autoLevelsClip)
+		stretch(values, lo, hi)
+	}
+	if t.blackPoint != 0 || t.whitePoint != 1 {
+		stretch(values, t.blackPoint, t.whitePoint)
+	}
+
+	switch t.equalize {
+	case "histogram":
+		equalizeHistogram(values)
+	case "clahe":
+		equalizeCLAHE(values, width, height, t.clipLimit)
+	}
+
+	for k, v := range values {
+		v = (v-0.5)*t.contrast + 0.5 + t.brightness
+		v = math.Pow(clampFloat(v, 0, 1), 1/t.gamma)
+		if t.invert {
+			v = 1 - v
+		}
+		values[k] = v
+	}
+}
+
+// stretch 将 [lo, hi] 线性拉伸到 [0, 1]
+func stretch(values []float64, lo, hi float64) {
+	if hi <= lo {
+		return
+	}
+	for k, v := range values {
+		values[k] = clampFloat((v-lo)/(hi-lo), 0, 1)
+	}
+}
+
+// percentiles 返回去掉两端 clip 比例后的最小值和最大值
+func percentiles(values []float64, clip float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 1
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	k := int(clip * float64(len(sorted)))
+	return sorted[k], sorted[len(sorted)-1-k]
+}
+
+// histogram 统计亮度值的直方图
+func histogram(values []float64) []float64 {
+	hist := make([]float64, histogramBins)
+	for _, v := range values {
+		hist[binOf(v)]++
+	}
+	return hist
+}
+
+// binOf 返回亮度值所在的直方图区间
+func binOf(v float64) int {
+	return clampInt(int(v*(histogramBins-1)+0.5), 0, histogramBins-1)
+}
+
+// cdfOf 将直方图转换为归一化的累积分布
+func cdfOf(hist []float64) []float64 {
+	cdf := make([]float64, len(hist))
+	var total, sum float64
+	for _, h := range hist {
+		total += h
+	}
+	if total == 0 {
+		return cdf
+	}
+	for i, h := range hist {
+		sum += h
+		cdf[i] = sum / total
+	}
+	return cdf
+}
+
+// equalizeHistogram 全局直方图均衡
+func equalizeHistogram(values []float64) {
+	cdf := cdfOf(histogram(values))
+	for k, v := range values {
+		values[k] = cdf[binOf(v)]
+	}
+}
+
+// equalizeCLAHE 限制对比度的自适应直方图均衡：网格分为 claheTiles x claheTiles 块，
+// 每块的直方图按 clipLimit 倍平均值截断并重新分配，块之间双线性插值。
+func equalizeCLAHE(values []float64, width, height int, clipLimit float64) {
+	tilesX := minInt(claheTiles, width)
+	tilesY := minInt(claheTiles, height)
+	if tilesX == 0 || tilesY == 0 {
+		return
+	}
+
+	// 计算每个块的累积分布
+	cdfs := make([][]float64, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		y0, y1 := subRange(0, height, ty, tilesY)
+		for tx := 0; tx < tilesX; tx++ {
+			x0, x1 := subRange(0, width, tx, tilesX)
+			hist := make([]float64, histogramBins)
+			var count float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					hist[binOf(values[y*width+x])]++
+					count++
+				}
+			}
+			// 与常见实现一致，截断阈值至少为 1，避免小块的直方图被完全抹平
+			clipHistogram(hist, math.Max(clipLimit*count/histogramBins, 1))
+			cdfs[ty*tilesX+tx] = cdfOf(hist)
+		}
+	}
+
+	// 在相邻块中心之间双线性插值
+	tileW := float64(width) / float64(tilesX)
+	tileH := float64(height) / float64(tilesY)
+	result := make([]float64, len(values))
+	for y := 0; y < height; y++ {
+		fy := clampFloat((float64(y)+0.5)/tileH-0.5, 0, float64(tilesY-1))
+		ty0 := int(fy)
+		ty1 := minInt(ty0+1, tilesY-1)
+		wy := fy - float64(ty0)
+		for x := 0; x < width; x++ {
+			fx := clampFloat((float64(x)+0.5)/tileW-0.5, 0, float64(tilesX-1))
+			tx0 := int(fx)
+			tx1 := minInt(tx0+1, tilesX-1)
+			wx := fx - float64(tx0)
+
+			bin := binOf(values[y*width+x])
+			top := cdfs[ty0*tilesX+tx0][bin]*(1-wx) + cdfs[ty0*tilesX+tx1][bin]*wx
+			bottom := cdfs[ty1*tilesX+tx0][bin]*(1-wx) + cdfs[ty1*tilesX+tx1][bin]*wx
+			result[y*width+x] = top*(1-wy) + bottom*wy
+		}
+	}
+	copy(values, result)
+}
+
+// clipHistogram 截断超过 limit 的直方图区间，并把多出的计数平均分配到所有区间
+func clipHistogram(hist []float64, limit float64) {
+	var excess float64
+	for i, h := range hist {
+		if h > limit {
+			excess += h - limit
+			hist[i] = limit
+		}
+	}
+	share := excess / float64(len(hist))
+	for i := range hist {
+		hist[i] += share
+	}
+}
+
+// clampFloat 将浮点数限制在 [lo, hi] 范围内
+func clampFloat(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// minInt 返回两个整数中较小的一个
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt 返回两个整数中较大的一个
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
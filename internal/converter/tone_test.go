@@ -0,0 +1,96 @@
+package converter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+)
+
+// 测试逐点的色调映射参数
+func TestToneMapPointwise(t *testing.T) {
+	tests := []struct {
+		name       string
+		background string
+		tone       config.ToneConfig
+		input      []float64
+		expected   []float64
+	}{
+		{"identity", "black", config.ToneConfig{}, []float64{0, 0.3, 1}, []float64{0, 0.3, 1}},
+		{"auto invert on white", "white", config.ToneConfig{}, []float64{0, 0.3, 1}, []float64{1, 0.7, 0}},
+		{"no auto invert on black", "black", config.ToneConfig{}, []float64{0, 0.3, 1}, []float64{0, 0.3, 1}},
+		{"no invert on white", "white", config.ToneConfig{Invert: "false"}, []float64{0.3}, []float64{0.3}},
+		{"forced invert on black", "black", config.ToneConfig{Invert: "true"}, []float64{0.3}, []float64{0.7}},
+		{"gamma", "black", config.ToneConfig{Gamma: 2}, []float64{0.25}, []float64{0.5}},
+		{"contrast", "black", config.ToneConfig{Contrast: 2}, []float64{0.25, 0.5, 0.75}, []float64{0, 0.5, 1}},
+		{"brightness", "black", config.ToneConfig{Brightness: 0.1}, []float64{0.5, 0.95}, []float64{0.6, 1}},
+		{"levels", "black", config.ToneConfig{BlackPoint: 0.2, WhitePoint: 0.8}, []float64{0.1, 0.5, 0.8}, []float64{0, 0.5, 1}},
+		{"auto levels", "black", config.ToneConfig{AutoLevels: true}, []float64{0.4, 0.5, 0.6}, []float64{0, 0.5, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tone, err := newToneMap(&config.Config{Background: test.background, Tone: test.tone})
+			if err != nil {
+				t.Fatalf("newToneMap failed: %v", err)
+			}
+			values := append([]float64(nil), test.input...)
+			tone.apply(values, len(values), 1)
+			for i := range values {
+				if math.Abs(values[i]-test.expected[i]) > 1e-9 {
+					t.Errorf("value %d: expected %f, got %f", i, test.expected[i], values[i])
+				}
+			}
+		})
+	}
+}
+
+// 测试直方图均衡和 CLAHE 扩展低对比度图像的亮度范围
+func TestToneMapEqualize(t *testing.T) {
+	const width, height = 32, 32
+	for _, method := range []string{"histogram", "clahe"} {
+		t.Run(method, func(t *testing.T) {
+			tone, err := newToneMap(&config.Config{Background: "black", Tone: config.ToneConfig{Equalize: method, ClaheClip: 40}})
+			if err != nil {
+				t.Fatalf("newToneMap failed: %v", err)
+			}
+
+			values := gradientValues(width, height)
+			for i := range values {
+				values[i] = 0.4 + 0.2*values[i]
+			}
+			tone.apply(values, width, height)
+
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, v := range values {
+				if v < 0 || v > 1 {
+					t.Fatalf("value %f out of range", v)
+				}
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			if hi-lo < 0.5 {
+				t.Errorf("expected equalization to widen the range, got [%f, %f]", lo, hi)
+			}
+
+			// 全局均衡不改变同一行内亮度的先后顺序；CLAHE 是局部映射，块边界处允许反转
+			if method != "histogram" {
+				return
+			}
+			for x := 1; x < width; x++ {
+				if values[x] < values[x-1] {
+					t.Errorf("equalization is not monotonic at %d", x)
+				}
+			}
+		})
+	}
+}
+
+// 测试未知的均衡方法和反转模式返回错误
+func TestNewToneMapUnknown(t *testing.T) {
+	if _, err := newToneMap(&config.Config{Tone: config.ToneConfig{Equalize: "unknown"}}); err == nil {
+		t.Error("expected error for unknown equalization")
+	}
+	if _, err := newToneMap(&config.Config{Tone: config.ToneConfig{Invert: "sometimes"}}); err == nil {
+		t.Error("expected error for unknown invert mode")
+	}
+}
@@ -1,19 +1,40 @@
 package converter
 
 import (
+    "context"
     "fmt"
+    "image"
+    "io"
+    "io/ioutil"
     "os"
     "os/exec"
     "path/filepath"
+    "sort"
+    "strconv"
     "strings"
-    "image/jpeg"
-    "io/ioutil"
 
     "github.com/hai119/Go-ASCII-generator/internal/config"
+    "github.com/hai119/Go-ASCII-generator/internal/progress"
+    "github.com/hai119/Go-ASCII-generator/internal/utils"
 )
 
+// frameRate video2text 和 video2video 读取视频帧的帧率
+const frameRate = 10
+
 // VideoToText converts video to ASCII text
+// Animated GIFs are decoded without ffmpeg.
 func VideoToText(cfg *config.Config) error {
+    return VideoToTextContext(context.Background(), cfg)
+}
+
+// VideoToTextContext 与 VideoToText 相同，ctx 取消时终止 ffmpeg 并停止处理帧，不会留下不完整的输出文件
+func VideoToTextContext(ctx context.Context, cfg *config.Config) error {
+    p, err := NewPipeline(cfg)
+    if err != nil {
+        return err
+    }
+    defer p.Close()
+
     // 创建临时目录存放帧
     tempDir, err := ioutil.TempDir("", "ascii-frames-")
     if err != nil {
@@ -21,85 +42,162 @@ func VideoToText(cfg *config.Config) error {
     }
     defer os.RemoveAll(tempDir)
 
-    // 使用ffmpeg提取帧
-    framePattern := filepath.Join(tempDir, "frame-%d.jpg")
-    cmd := exec.Command("ffmpeg", "-i", cfg.InputPath, "-vf", "fps=10", framePattern)
-    if err := cmd.Run(); err != nil {
-        return fmt.Errorf("failed to extract frames: %v", err)
+    // 使用ffmpeg提取帧，动画 GIF 直接解码
+    frames, err := openVideoFrames(ctx, cfg.InputPath, tempDir, "frame", false)
+    if err != nil {
+        return err
     }
 
-    // 创建输出文件
-    outputDir := filepath.Dir(cfg.OutputPath)
-    if err := os.MkdirAll(outputDir, 0755); err != nil {
-        return fmt.Errorf("failed to create output directory: %v", err)
-    }
+    // 创建输出文件，"-" 时直接写入标准输出
+    var out io.Writer = os.Stdout
+    var output *utils.AtomicFile
+    if cfg.OutputPath != stdoutPath {
+        outputDir := filepath.Dir(cfg.OutputPath)
+        if err := os.MkdirAll(outputDir, 0755); err != nil {
+            return fmt.Errorf("failed to create output directory: %v", err)
+        }
 
-    output, err := os.Create(cfg.OutputPath)
-    if err != nil {
-        return fmt.Errorf("failed to create output file: %v", err)
+        output, err = utils.CreateAtomic(cfg.OutputPath)
+        if err != nil {
+            return fmt.Errorf("failed to create output file: %v", err)
+        }
+        defer output.Close()
+        out = output
     }
-    defer output.Close()
 
     // 处理每一帧
-    frameFiles, err := filepath.Glob(filepath.Join(tempDir, "frame-*.jpg"))
-    if err != nil {
-        return fmt.Errorf("failed to list frames: %v", err)
-    }
+    renderer := p.TextRenderer()
+    tracker := progress.NewTracker(ctx, progress.StageFrames, frames.Len())
+    for frameNum := 0; frameNum < frames.Len(); frameNum++ {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
 
-    for frameNum, framePath := range frameFiles {
-        // 读取帧
-        file, err := os.Open(framePath)
+        img, err := frames.Frame(frameNum)
         if err != nil {
-            return fmt.Errorf("failed to open frame: %v", err)
+            return err
         }
-
-        img, err := jpeg.Decode(file)
-        file.Close()
+        grid, err := p.Analyze(ctx, img)
         if err != nil {
-            return fmt.Errorf("failed to decode frame: %v", err)
+            return err
         }
 
-        // 获取帧尺寸
-        bounds := img.Bounds()
-        width, height := bounds.Max.X, bounds.Max.Y
-
-        // 计算单元格大小
-        cellWidth := float64(width) / float64(cfg.NumCols)
-        cellHeight := 2 * cellWidth
-        numRows := int(float64(height) / cellHeight)
-
-        // 获取字符集
-        chars := getCharList(cfg.CharMode)
-        numChars := len(chars)
-
         // 生成ASCII帧
         var frameText strings.Builder
         frameText.WriteString(fmt.Sprintf("Frame %d:\n", frameNum))
-
-        for i := 0; i < numRows; i++ {
-            for j := 0; j < cfg.NumCols; j++ {
-                brightness := calculateBrightness(img,
-                    int(float64(j)*cellWidth),
-                    int(float64(i)*cellHeight),
-                    int(cellWidth),
-                    int(cellHeight))
-
-                charIndex := int(brightness * float64(numChars-1))
-                if charIndex >= numChars {
-                    charIndex = numChars - 1
-                }
-
-                frameText.WriteString(string(chars[charIndex]))
-            }
-            frameText.WriteString("\n")
+        if err := renderer.Render(ctx, &frameText, grid); err != nil {
+            return err
         }
         frameText.WriteString("\n")
 
         // 写入输出文件
-        if _, err := output.WriteString(frameText.String()); err != nil {
+        n, err := io.WriteString(out, frameText.String())
+        if err != nil {
             return fmt.Errorf("failed to write frame: %v", err)
         }
+        tracker.AddBytes(int64(n))
+        tracker.Add(1)
     }
 
+    if output == nil {
+        return nil
+    }
+    return output.Commit()
+}
+
+// checkVideoOutput 视频由 ffmpeg 或 GIF 编码器写入文件，输出路径为 "-" 时返回错误
+func checkVideoOutput(path string) error {
+    if path == stdoutPath {
+        return fmt.Errorf("video output cannot be written to stdout, use -output with a file path")
+    }
     return nil
-} 
\ No newline at end of file
+}
+
+// videoFrames 视频的各帧：动画 GIF 在内存中解码，其他视频由 ffmpeg 提取为文件
+type videoFrames struct {
+    files  []string
+    images []image.Image
+    // delays 每帧的显示时长，单位为 1/100 秒
+    delays []int
+}
+
+// openVideoFrames 以每秒 frameRate 帧读取视频的各帧，提取的文件存放在 dir 中。
+// keepTiming 为 true 时动画 GIF 保留原有的帧和帧延迟，不重新采样。
+func openVideoFrames(ctx context.Context, input, dir, prefix string, keepTiming bool) (*videoFrames, error) {
+    anim, err := decodeGIFFile(input)
+    if err != nil {
+        return nil, err
+    }
+    if anim == nil {
+        files, err := extractFrames(ctx, input, dir, prefix)
+        if err != nil {
+            return nil, err
+        }
+        return &videoFrames{files: files}, nil
+    }
+
+    tracker := progress.NewTracker(ctx, progress.StageExtract, 0)
+    frames := &videoFrames{}
+    if keepTiming {
+        for _, img := range anim.Frames {
+            frames.images = append(frames.images, img)
+        }
+        frames.delays = anim.Delays
+    } else {
+        frames.images = anim.Sample(frameRate)
+    }
+    tracker.Add(frames.Len())
+    tracker.Finish()
+    return frames, nil
+}
+
+// Len 返回帧数
+func (v *videoFrames) Len() int {
+    if v.files != nil {
+        return len(v.files)
+    }
+    return len(v.images)
+}
+
+// Frame 返回第 i 帧
+func (v *videoFrames) Frame(i int) (image.Image, error) {
+    if v.files != nil {
+        return decodeImageFile(v.files[i])
+    }
+    return v.images[i], nil
+}
+
+// Delay 返回第 i 帧的显示时长，单位为 1/100 秒
+func (v *videoFrames) Delay(i int) int {
+    if v.delays != nil {
+        return v.delays[i]
+    }
+    return 100 / frameRate
+}
+
+// extractFrames 使用 ffmpeg 以每秒 frameRate 帧将视频提取为 dir 中名为 prefix-N.jpg 的图像，按帧序返回帧文件路径
+func extractFrames(ctx context.Context, input, dir, prefix string) ([]string, error) {
+    tracker := progress.NewTracker(ctx, progress.StageExtract, 0)
+    framePattern := filepath.Join(dir, prefix+"-%d.jpg")
+    cmd := exec.CommandContext(ctx, "ffmpeg", "-i", input, "-vf", fmt.Sprintf("fps=%d", frameRate), framePattern)
+    if err := cmd.Run(); err != nil {
+        if ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
+        return nil, fmt.Errorf("failed to extract frames: %v", err)
+    }
+
+    frameFiles, err := filepath.Glob(filepath.Join(dir, prefix+"-*.jpg"))
+    if err != nil {
+        return nil, fmt.Errorf("failed to list frames: %v", err)
+    }
+    // 按帧号排序，按文件名排序时 prefix-10 会排在 prefix-2 之前
+    frameNumber := func(path string) int {
+        n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), prefix+"-"), ".jpg"))
+        return n
+    }
+    sort.Slice(frameFiles, func(i, j int) bool { return frameNumber(frameFiles[i]) < frameNumber(frameFiles[j]) })
+    tracker.Add(len(frameFiles))
+    tracker.Finish()
+    return frameFiles, nil
+}
@@ -1,18 +1,46 @@
 package converter
 
 import (
+    "context"
     "fmt"
+    "image"
+    "io"
     "os"
     "os/exec"
     "path/filepath"
-    "image/jpeg"
+    "strconv"
+    "strings"
     "io/ioutil"
+    "time"
 
-    "github.com/fogleman/gg"
     "github.com/hai119/Go-ASCII-generator/internal/config"
+    "github.com/hai119/Go-ASCII-generator/internal/encoder"
+    "github.com/hai119/Go-ASCII-generator/internal/progress"
+    "github.com/hai119/Go-ASCII-generator/internal/utils"
 )
 
+// VideoToVideoColor converts video to colored ASCII art video
+// A .gif output is encoded as an animated GIF without ffmpeg, keeping the frame delays of a GIF input.
 func VideoToVideoColor(cfg *config.Config) error {
+    return VideoToVideoColorContext(context.Background(), cfg)
+}
+
+// VideoToVideoColorContext 与 VideoToVideoColor 相同，ctx 取消时终止 ffmpeg 并停止处理帧，不会留下不完整的输出视频
+func VideoToVideoColorContext(ctx context.Context, cfg *config.Config) error {
+    return videoToVideo(ctx, cfg, false)
+}
+
+// videoToVideo 将视频的每一帧转换为字符画图像并编码为 cfg.OutputPath 的视频，mono 为 true 时使用单色字符
+func videoToVideo(ctx context.Context, cfg *config.Config, mono bool) error {
+    if err := checkVideoOutput(cfg.OutputPath); err != nil {
+        return err
+    }
+    p, err := NewPipeline(cfg)
+    if err != nil {
+        return err
+    }
+    defer p.Close()
+
     // 创建临时目录
     tempDir, err := ioutil.TempDir("", "ascii-frames-")
     if err != nil {
@@ -20,11 +48,15 @@ func VideoToVideoColor(cfg *config.Config) error {
     }
     defer os.RemoveAll(tempDir)
 
-    // 提取原始帧
-    inputFramePattern := filepath.Join(tempDir, "input-frame-%d.jpg")
-    cmd := exec.Command("ffmpeg", "-i", cfg.InputPath, "-vf", "fps=10", inputFramePattern)
-    if err := cmd.Run(); err != nil {
-        return fmt.Errorf("failed to extract frames: %v", err)
+    // 提取原始帧，输出 GIF 时保留 GIF 输入的帧延迟
+    gifOutput := strings.EqualFold(cfg.Format, "gif") || strings.EqualFold(filepath.Ext(cfg.OutputPath), ".gif")
+    frames, err := openVideoFrames(ctx, cfg.InputPath, tempDir, "input-frame", gifOutput)
+    if err != nil {
+        return err
+    }
+    renderer := p.ImageRenderer(mono)
+    if gifOutput {
+        return encodeGIFFrames(ctx, cfg.OutputPath, frames, p, renderer)
     }
 
     // 创建输出目录
@@ -33,101 +65,117 @@ func VideoToVideoColor(cfg *config.Config) error {
         return fmt.Errorf("failed to create output frame directory: %v", err)
     }
 
-    // 处理每一帧
-    frameFiles, err := filepath.Glob(filepath.Join(tempDir, "input-frame-*.jpg"))
-    if err != nil {
-        return fmt.Errorf("failed to list frames: %v", err)
+    // 处理每一帧，中间帧使用快速压缩的 PNG，避免合成视频前的 JPEG 二次压缩损失
+    png, _ := encoder.Lookup("png")
+    renderer.Encode = func(w io.Writer, img image.Image) error {
+        return png.Encode(w, img, encoder.Options{Compression: "fast"})
     }
-
-    for _, framePath := range frameFiles {
-        // 读取帧
-        file, err := os.Open(framePath)
-        if err != nil {
-            return fmt.Errorf("failed to open frame: %v", err)
+    tracker := progress.NewTracker(ctx, progress.StageFrames, frames.Len())
+    for i := 0; i < frames.Len(); i++ {
+        if err := ctx.Err(); err != nil {
+            return err
         }
 
-        img, err := jpeg.Decode(file)
-        file.Close()
+        img, err := frames.Frame(i)
         if err != nil {
-            return fmt.Errorf("failed to decode frame: %v", err)
-        }
-
-        // 处理帧
-        bounds := img.Bounds()
-        width, height := bounds.Max.X, bounds.Max.Y
-
-        // 创建输出图像
-        dc := gg.NewContext(width, height)
-        if cfg.Background == "white" {
-            dc.SetRGB(1, 1, 1)
-        } else {
-            dc.SetRGB(0, 0, 0)
-        }
-        dc.Clear()
-
-        // 设置字体
-        if err := dc.LoadFontFace("fonts/DejaVuSansMono-Bold.ttf", 12*cfg.Scale); err != nil {
-            return fmt.Errorf("failed to load font: %v", err)
+            return err
         }
-
-        // 计算单元格大小
-        cellWidth := float64(width) / float64(cfg.NumCols)
-        cellHeight := 2 * cellWidth
-        numRows := int(float64(height) / cellHeight)
-
-        // 获取字符集
-        chars := getCharList(cfg.CharMode)
-        numChars := len(chars)
-
-        // 转换为ASCII艺术
-        for i := 0; i < numRows; i++ {
-            for j := 0; j < cfg.NumCols; j++ {
-                avgColor := calculateAverageColor(img,
-                    int(float64(j)*cellWidth),
-                    int(float64(i)*cellHeight),
-                    int(cellWidth),
-                    int(cellHeight))
-
-                brightness := calculateColorBrightness(avgColor)
-                charIndex := int(brightness * float64(numChars-1))
-                if charIndex >= numChars {
-                    charIndex = numChars - 1
-                }
-
-                x := float64(j) * cellWidth
-                y := float64(i) * cellHeight + cellHeight/2
-
-                dc.SetColor(avgColor)
-                dc.DrawStringAnchored(string(chars[charIndex]), x, y, 0, 0.5)
-            }
+        grid, err := p.Analyze(ctx, img)
+        if err != nil {
+            return err
         }
 
-        // 保存处理后的帧
-        outputFramePath := filepath.Join(outputFrameDir, filepath.Base(framePath))
+        // 转换为ASCII艺术并保存处理后的帧
+        outputFramePath := filepath.Join(outputFrameDir, fmt.Sprintf("frame-%d.png", i+1))
         outFile, err := os.Create(outputFramePath)
         if err != nil {
             return fmt.Errorf("failed to create output frame: %v", err)
         }
 
-        if err := jpeg.Encode(outFile, dc.Image(), nil); err != nil {
-            outFile.Close()
-            return fmt.Errorf("failed to encode output frame: %v", err)
-        }
+        err = renderer.Render(ctx, outFile, grid)
         outFile.Close()
+        if err != nil {
+            return err
+        }
+        tracker.Add(1)
+    }
+
+    // 合成视频，ffmpeg 写入同目录下的临时文件，完成后才替换目标文件
+    output, err := utils.CreateAtomic(cfg.OutputPath)
+    if err != nil {
+        return fmt.Errorf("failed to create output file: %v", err)
     }
+    defer output.Close()
 
-    // 合成视频
-    outputFramePattern := filepath.Join(outputFrameDir, "input-frame-%d.jpg")
-    cmd = exec.Command("ffmpeg",
-        "-framerate", "10",
+    outputFramePattern := filepath.Join(outputFrameDir, "frame-%d.png")
+    cmd := exec.CommandContext(ctx, "ffmpeg",
+        "-y",
+        "-framerate", strconv.Itoa(frameRate),
         "-i", outputFramePattern,
         "-c:v", "libx264",
         "-pix_fmt", "yuv420p",
-        cfg.OutputPath)
-    
-    if err := cmd.Run(); err != nil {
+        output.Name())
+
+    // 编码期间轮询输出文件的大小作为已写出的字节数
+    encoding := progress.NewTracker(ctx, progress.StageEncode, 0)
+    stopWatch := encoding.WatchFile(output.Name(), 500*time.Millisecond)
+    err = cmd.Run()
+    stopWatch()
+    if err != nil {
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
         return fmt.Errorf("failed to create output video: %v", err)
     }
+    if info, err := os.Stat(output.Name()); err == nil {
+        encoding.SetBytes(info.Size())
+    }
+    encoding.Finish()
+
+    return output.Commit()
+}
+
+// encodeGIFFrames 将每一帧转换为字符画图像，按帧延迟编码为 path 的动画 GIF，调色板由渲染后的帧生成
+func encodeGIFFrames(ctx context.Context, path string, frames *videoFrames, p *Pipeline, renderer *ImageRenderer) error {
+    output, err := utils.CreateAtomic(path)
+    if err != nil {
+        return fmt.Errorf("failed to create output file: %v", err)
+    }
+    defer output.Close()
+
+    gifEncoder := NewGIFEncoder(output, frameRate)
+    tracker := progress.NewTracker(ctx, progress.StageFrames, frames.Len())
+    for i := 0; i < frames.Len(); i++ {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
+        img, err := frames.Frame(i)
+        if err != nil {
+            return err
+        }
+        grid, err := p.Analyze(ctx, img)
+        if err != nil {
+            return err
+        }
+        frame, err := renderer.Draw(ctx, grid)
+        if err != nil {
+            return err
+        }
+        if err := gifEncoder.WriteFrameDelay(frame, frames.Delay(i)); err != nil {
+            return err
+        }
+        tracker.Add(1)
+    }
+
+    encoding := progress.NewTracker(ctx, progress.StageEncode, 0)
+    if err := gifEncoder.Close(); err != nil {
+        return err
+    }
+    if info, err := os.Stat(output.Name()); err == nil {
+        encoding.SetBytes(info.Size())
+    }
+    encoding.Finish()
 
-    return nil
-} 
\ No newline at end of file
+    return output.Commit()
+}
@@ -1,42 +1,45 @@
 package converter
 
 import (
-    "image"
-    "image/color"
+    "context"
+    "runtime"
     "sync"
+
+    "github.com/hai119/Go-ASCII-generator/internal/progress"
 )
 
 // WorkerPool 工作池结构
+// 固定数量的工作协程处理按行或按块划分的任务，结果按任务顺序重新组装。
+// 同一个工作池可以被多次调用共享，例如视频的所有帧；任务内部不能再向同一个工作池提交任务。
 type WorkerPool struct {
     numWorkers int
     jobs       chan Job
-    results    chan Result
     wg         sync.WaitGroup
 }
 
-// Job 工作单元
+// Job 工作单元，处理 [start, end) 范围内的行或块
 type Job struct {
-    img        image.Image
-    row        int
-    col        int
-    cellWidth  int
-    cellHeight int
+    index   int
+    start   int
+    end     int
+    run     func(start, end int) interface{}
+    results chan<- Result
 }
 
-// Result 处理结果
+// Result 处理结果，index 为任务在本次调用中的序号
 type Result struct {
-    row        int
-    col        int
-    brightness float64
-    avgColor   color.Color
+    index int
+    value interface{}
 }
 
-// NewWorkerPool 创建新的工作池
+// NewWorkerPool 创建新的工作池，numWorkers 不大于 0 时使用 GOMAXPROCS
 func NewWorkerPool(numWorkers int) *WorkerPool {
+    if numWorkers <= 0 {
+        numWorkers = runtime.GOMAXPROCS(0)
+    }
     return &WorkerPool{
         numWorkers: numWorkers,
         jobs:       make(chan Job, numWorkers*2),
-        results:    make(chan Result, numWorkers*2),
     }
 }
 
@@ -52,33 +55,124 @@ func (p *WorkerPool) Start() {
 func (p *WorkerPool) Stop() {
     close(p.jobs)
     p.wg.Wait()
-    close(p.results)
+}
+
+// Size 返回工作协程数量，nil 工作池为 1
+func (p *WorkerPool) Size() int {
+    if p == nil {
+        return 1
+    }
+    return p.numWorkers
+}
+
+// Map 将 [0, n) 按每块 chunk 个划分为任务并行执行，按任务顺序返回每个任务的结果。
+// nil 工作池在当前协程中顺序执行。
+func (p *WorkerPool) Map(n, chunk int, run func(start, end int) interface{}) []interface{} {
+    values, _ := p.MapContext(context.Background(), n, chunk, run)
+    return values
+}
+
+// MapContext 与 Map 相同，但 ctx 取消后不再分发剩余的任务，
+// 等待已分发的任务结束后返回 ctx 的错误，此时未执行任务的结果为 nil。
+// ctx 通过 progress.WithStage 标记了阶段时，每完成一个任务向 ctx 中的报告器发送一次进度事件。
+func (p *WorkerPool) MapContext(ctx context.Context, n, chunk int, run func(start, end int) interface{}) ([]interface{}, error) {
+    if n <= 0 {
+        return nil, ctx.Err()
+    }
+    if chunk <= 0 {
+        chunk = 1
+    }
+    numJobs := (n + chunk - 1) / chunk
+    values := make([]interface{}, numJobs)
+
+    var tracker *progress.Tracker
+    if stage := progress.StageFrom(ctx); stage != "" {
+        tracker = progress.NewTracker(ctx, stage, numJobs)
+    }
+
+    if p == nil {
+        for i := 0; i < numJobs; i++ {
+            if err := ctx.Err(); err != nil {
+                return values, err
+            }
+            values[i] = run(i*chunk, minInt((i+1)*chunk, n))
+            if tracker != nil {
+                tracker.Add(1)
+            }
+        }
+        return values, nil
+    }
+
+    // 结果通道的容量足够容纳所有结果，工作协程不会因为等待收集而阻塞
+    results := make(chan Result, numJobs)
+    dispatched := make(chan int, 1)
+    go func() {
+        i := 0
+    dispatch:
+        for ; i < numJobs && ctx.Err() == nil; i++ {
+            job := Job{
+                index:   i,
+                start:   i * chunk,
+                end:     minInt((i+1)*chunk, n),
+                run:     run,
+                results: results,
+            }
+            select {
+            case p.jobs <- job:
+            case <-ctx.Done():
+                break dispatch
+            }
+        }
+        dispatched <- i
+    }()
+
+    // 收集结果，直到分发的任务全部完成
+    total, received := -1, 0
+    for total < 0 || received < total {
+        select {
+        case result := <-results:
+            values[result.index] = result.value
+            received++
+            if tracker != nil {
+                tracker.Add(1)
+            }
+        case total = <-dispatched:
+        }
+    }
+    if total < numJobs {
+        return values, ctx.Err()
+    }
+    return values, nil
+}
+
+// Rows 并行处理 [0, n) 中的每一行，行按块分发以减少调度开销
+func (p *WorkerPool) Rows(n int, fn func(row int)) {
+    p.RowsContext(context.Background(), n, fn)
+}
+
+// RowsContext 与 Rows 相同，ctx 取消后剩余的行不再处理并返回 ctx 的错误
+func (p *WorkerPool) RowsContext(ctx context.Context, n int, fn func(row int)) error {
+    _, err := p.MapContext(ctx, n, p.rowChunk(n), func(start, end int) interface{} {
+        for row := start; row < end; row++ {
+            fn(row)
+        }
+        return nil
+    })
+    return err
+}
+
+// rowChunk 返回每个任务处理的行数，使任务数约为工作协程数的四倍以均衡负载
+func (p *WorkerPool) rowChunk(n int) int {
+    return maxInt(1, (n+p.Size()*4-1)/(p.Size()*4))
 }
 
 // worker 工作协程
 func (p *WorkerPool) worker() {
     defer p.wg.Done()
     for job := range p.jobs {
-        x := job.col * job.cellWidth
-        y := job.row * job.cellHeight
-
-        brightness := calculateBrightness(job.img,
-            x,
-            y,
-            job.cellWidth,
-            job.cellHeight)
-
-        avgColor := calculateAverageColor(job.img,
-            x,
-            y,
-            job.cellWidth,
-            job.cellHeight)
-
-        p.results <- Result{
-            row:        job.row,
-            col:        job.col,
-            brightness: brightness,
-            avgColor:   avgColor,
+        job.results <- Result{
+            index: job.index,
+            value: job.run(job.start, job.end),
         }
     }
-} 
\ No newline at end of file
+}
@@ -1,10 +1,20 @@
 package converter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"runtime"
+	"sync"
 	"testing"
+
+	"github.com/fogleman/gg"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/fonts"
 )
 
 // 模拟图像生成
@@ -18,224 +28,244 @@ func generateTestImage(width, height int, color color.Color) image.Image {
 	return img
 }
 
-// 测试 WorkerPool
-func testWorkerPool(t *testing.T) {
-	// 测试用例：纯红色图像
-	t.Run("TestPureRedImage", func(t *testing.T) {
-		img := generateTestImage(100, 100, color.RGBA{255, 0, 0, 255}) // 纯红色图像
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		cellWidth := 10
-		cellHeight := 10
-		for row := 0; row < 10; row++ {
-			for col := 0; col < 10; col++ {
-				pool.jobs <- Job{
-					img:        img,
-					row:        row,
-					col:        col,
-					cellWidth:  cellWidth,
-					cellHeight: cellHeight,
-				}
-			}
-		}
-
-		pool.Stop()
+// 测试 WorkerPool 按任务顺序返回结果
+func TestWorkerPoolMapOrder(t *testing.T) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			pool := NewWorkerPool(workers)
+			pool.Start()
+			defer pool.Stop()
 
-		// 验证结果
-		for result := range pool.results {
-			brightness := result.brightness
-			if math.Abs(brightness-0.2989) > 0.01 {
-				t.Errorf("Expected brightness around 0.2989 for red, got: %f", brightness)
+			values := pool.Map(100, 7, func(start, end int) interface{} {
+				return [2]int{start, end}
+			})
+			if len(values) != 15 {
+				t.Fatalf("expected 15 results, got %d", len(values))
 			}
-		}
-	})
-
-	// 测试用例：纯绿色图像
-	t.Run("TestPureGreenImage", func(t *testing.T) {
-		img := generateTestImage(100, 100, color.RGBA{0, 255, 0, 255}) // 纯绿色图像
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		cellWidth := 10
-		cellHeight := 10
-		for row := 0; row < 10; row++ {
-			for col := 0; col < 10; col++ {
-				pool.jobs <- Job{
-					img:        img,
-					row:        row,
-					col:        col,
-					cellWidth:  cellWidth,
-					cellHeight: cellHeight,
+			for i, value := range values {
+				expected := [2]int{i * 7, minInt((i+1)*7, 100)}
+				if value.([2]int) != expected {
+					t.Errorf("result %d: expected %v, got %v", i, expected, value)
 				}
 			}
-		}
-
-		pool.Stop()
+		})
+	}
+}
 
-		// 验证结果
-		for result := range pool.results {
-			brightness := result.brightness
-			if math.Abs(brightness-0.5870) > 0.01 {
-				t.Errorf("Expected brightness around 0.5870 for green, got: %f", brightness)
-			}
-		}
+// 测试 nil 工作池在当前协程中顺序执行
+func TestWorkerPoolNil(t *testing.T) {
+	var pool *WorkerPool
+	var visited []int
+	pool.Rows(5, func(row int) {
+		visited = append(visited, row)
 	})
+	for i, row := range visited {
+		if row != i {
+			t.Fatalf("expected rows in order, got %v", visited)
+		}
+	}
+	if len(visited) != 5 {
+		t.Errorf("expected 5 rows, got %d", len(visited))
+	}
+}
 
-	// 测试用例：纯蓝色图像
-	t.Run("TestPureBlueImage", func(t *testing.T) {
-		img := generateTestImage(100, 100, color.RGBA{0, 0, 255, 255}) // 纯蓝色图像
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		cellWidth := 10
-		cellHeight := 10
-		for row := 0; row < 10; row++ {
-			for col := 0; col < 10; col++ {
-				pool.jobs <- Job{
-					img:        img,
-					row:        row,
-					col:        col,
-					cellWidth:  cellWidth,
-					cellHeight: cellHeight,
+// 测试多个调用并发共享同一个工作池，例如视频的多帧
+func TestWorkerPoolShared(t *testing.T) {
+	pool := NewWorkerPool(3)
+	pool.Start()
+	defer pool.Stop()
+
+	var wg sync.WaitGroup
+	for caller := 0; caller < 8; caller++ {
+		wg.Add(1)
+		go func(caller int) {
+			defer wg.Done()
+			counts := make([]int, 50)
+			pool.Rows(len(counts), func(row int) {
+				counts[row] += caller + 1
+			})
+			for row, count := range counts {
+				if count != caller+1 {
+					t.Errorf("caller %d row %d: expected %d, got %d", caller, row, caller+1, count)
 				}
 			}
-		}
-
-		pool.Stop()
+		}(caller)
+	}
+	wg.Wait()
+}
 
-		// 验证结果
-		for result := range pool.results {
-			brightness := result.brightness
-			if math.Abs(brightness-0.1140) > 0.01 {
-				t.Errorf("Expected brightness around 0.1140 for blue, got: %f", brightness)
-			}
+// 测试 ctx 取消后不再分发剩余的任务
+func TestWorkerPoolMapContext(t *testing.T) {
+	pool := NewWorkerPool(2)
+	pool.Start()
+	defer pool.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var ran int
+	values, err := pool.MapContext(ctx, 1000, 1, func(start, end int) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		ran++
+		if ran == 10 {
+			cancel()
 		}
+		return start
 	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if ran >= 1000 {
+		t.Errorf("expected remaining jobs to be skipped, ran %d", ran)
+	}
+	if len(values) != 1000 || values[999] != nil {
+		t.Errorf("expected skipped jobs to have nil results")
+	}
 
-	// 测试用例：渐变图像
-	t.Run("TestGradientImage", func(t *testing.T) {
-		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
-		for y := 0; y < 100; y++ {
-			for x := 0; x < 100; x++ {
-				r := uint8((float64(x) / 100) * 255)
-				g := uint8((float64(y) / 100) * 255)
-				b := uint8((float64(x+y) / 200) * 255)
-				img.Set(x, y, color.RGBA{r, g, b, 255})
-			}
-		}
+	// 取消后工作池仍可继续使用
+	if _, err := pool.MapContext(context.Background(), 10, 1, func(start, end int) interface{} { return nil }); err != nil {
+		t.Errorf("expected pool to remain usable, got %v", err)
+	}
+}
 
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		cellWidth := 10
-		cellHeight := 10
-		for row := 0; row < 10; row++ {
-			for col := 0; col < 10; col++ {
-				pool.jobs <- Job{
-					img:        img,
-					row:        row,
-					col:        col,
-					cellWidth:  cellWidth,
-					cellHeight: cellHeight,
-				}
-			}
-		}
+// 测试工作协程数默认取 GOMAXPROCS
+func TestWorkerPoolDefaultSize(t *testing.T) {
+	if size := NewWorkerPool(0).Size(); size != runtime.GOMAXPROCS(0) {
+		t.Errorf("expected %d workers, got %d", runtime.GOMAXPROCS(0), size)
+	}
+}
 
-		pool.Stop()
+// 测试通过工作池采样纯色图像的亮度
+func TestWorkerPoolSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    color.Color
+		expected float64
+	}{
+		{"red", color.RGBA{255, 0, 0, 255}, 0.2989},
+		{"green", color.RGBA{0, 255, 0, 255}, 0.5870},
+		{"blue", color.RGBA{0, 0, 255, 255}, 0.1140},
+		{"white", color.RGBA{255, 255, 255, 255}, 1},
+	}
 
-		// 验证结果
-		for result := range pool.results {
-			brightness := result.brightness
-			if brightness < 0 || brightness > 1 {
-				t.Errorf("Invalid brightness value: %f", brightness)
+	pool := NewWorkerPool(4)
+	pool.Start()
+	defer pool.Stop()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := newSampler(generateTestImage(100, 100, test.color), srgbSpace, pool)
+			values := sampleSubcells(src, newCellLayout(src.bounds(), 10), 1, 1)
+			for _, v := range values {
+				if math.Abs(v-test.expected) > 0.01 {
+					t.Fatalf("expected brightness around %f, got %f", test.expected, v)
+				}
 			}
-		}
-	})
-
-	// 测试用例：无效图像路径
-	t.Run("TestInvalidImage", func(t *testing.T) {
-		img := generateTestImage(100, 100, color.RGBA{0, 0, 0, 255}) // 黑色图像
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		// 使用不存在的图像路径
-		img.(*image.RGBA).Set(101, 101, color.RGBA{255, 0, 0, 255}) // 超过图像范围，模拟错误
-
-		pool.jobs <- Job{
-			img:        img,
-			row:        0,
-			col:        0,
-			cellWidth:  10,
-			cellHeight: 10,
-		}
+		})
+	}
+}
 
-		pool.Stop()
+// 测试分块并行绘制与在单个画布上整体绘制的结果一致，分块边界处没有截断
+func TestDrawRunesBands(t *testing.T) {
+	cfg := &config.Config{Language: "english", CharMode: "simple", Scale: 1, Background: "black"}
+	cs, err := resolveCharset(cfg)
+	if err != nil {
+		t.Fatalf("resolveCharset failed: %v", err)
+	}
+	selector, err := newGlyphSelector(cs, cfg)
+	if err != nil {
+		t.Fatalf("newGlyphSelector failed: %v", err)
+	}
 
-		// 验证结果
-		for result := range pool.results {
-			brightness := result.brightness
-			if math.Abs(brightness) > 0.01 {
-				t.Errorf("Expected error or invalid brightness, got: %f", brightness)
-			}
-		}
-	})
+	pool := NewWorkerPool(4)
+	pool.Start()
+	defer pool.Stop()
 
-	// 测试用例：空图像
-	t.Run("TestEmptyImage", func(t *testing.T) {
-		img := image.NewRGBA(image.Rect(0, 0, 0, 0)) // 空图像
-		pool := NewWorkerPool(4)
-		pool.Start()
-
-		pool.jobs <- Job{
-			img:        img,
-			row:        0,
-			col:        0,
-			cellWidth:  10,
-			cellHeight: 10,
-		}
+	src := newSampler(noiseImage(160, 120), srgbSpace, pool)
+	layout := newCellLayout(src.bounds(), 20)
+	grid, err := analyze(context.Background(), src, selector, layout)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	banded, err := drawGrid(context.Background(), pool, grid, drawOptions{font: cs.font, background: color.Black})
+	if err != nil {
+		t.Fatalf("drawGrid failed: %v", err)
+	}
 
-		pool.Stop()
+	// 在单个画布上整体绘制作为参照
+	reference := image.NewRGBA(banded.Bounds())
+	dc := gg.NewContextForRGBA(reference)
+	dc.SetColor(color.Black)
+	dc.Clear()
+	if err := fonts.LoadFont(dc, cs.font); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	for i := 0; i < grid.Rows; i++ {
+		drawRow(dc, grid, i, 0, nil)
+	}
 
-		// 验证结果
-		for result := range pool.results {
-			if result.brightness != 0 {
-				t.Errorf("Expected brightness to be 0 for empty image, got: %f", result.brightness)
+	bounds := banded.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, _ := banded.At(x, y).RGBA()
+			r2, g2, b2, _ := reference.At(x, y).RGBA()
+			if absDiff(r1, r2) > 0x202 || absDiff(g1, g2) > 0x202 || absDiff(b1, b2) > 0x202 {
+				t.Fatalf("pixel (%d, %d) differs: %v vs %v", x, y, banded.At(x, y), reference.At(x, y))
 			}
 		}
-	})
-
-	// 测试用例：不同工作线程数量
-	t.Run("TestMultipleWorkers", func(t *testing.T) {
-		img := generateTestImage(100, 100, color.RGBA{255, 255, 255, 255}) // 白色图像
-
-		for _, workers := range []int{1, 2, 4, 8} {
-			pool := NewWorkerPool(workers)
-			pool.Start()
+	}
+}
 
-			cellWidth := 10
-			cellHeight := 10
-			for row := 0; row < 10; row++ {
-				for col := 0; col < 10; col++ {
-					pool.jobs <- Job{
-						img:        img,
-						row:        row,
-						col:        col,
-						cellWidth:  cellWidth,
-						cellHeight: cellHeight,
-					}
-				}
-			}
+// absDiff 返回两个无符号整数之差的绝对值
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
 
-			pool.Stop()
+// benchmarkConvert 对一张 1920x1080 的图像执行采样、选择字符和绘制
+func benchmarkConvert(b *testing.B, workers int, glyphMode string) {
+	cfg := &config.Config{Language: "english", CharMode: "complex", Scale: 1, Background: "black", GlyphMode: glyphMode}
+	cs, err := resolveCharset(cfg)
+	if err != nil {
+		b.Fatalf("resolveCharset failed: %v", err)
+	}
+	selector, err := newGlyphSelector(cs, cfg)
+	if err != nil {
+		b.Fatalf("newGlyphSelector failed: %v", err)
+	}
+	img := noiseImage(1920, 1080)
+
+	pool := NewWorkerPool(workers)
+	pool.Start()
+	defer pool.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		src := newSampler(img, srgbSpace, pool)
+		layout := newCellLayout(src.bounds(), 240)
+		grid, err := analyze(context.Background(), src, selector, layout)
+		if err != nil {
+			b.Fatalf("analyze failed: %v", err)
+		}
+		if _, err := drawGrid(context.Background(), pool, grid, drawOptions{font: cs.font, background: color.Black}); err != nil {
+			b.Fatalf("drawGrid failed: %v", err)
+		}
+	}
+}
 
-			// 验证结果
-			for result := range pool.results {
-				brightness := result.brightness
-				if math.Abs(brightness-1) > 0.01 {
-					t.Errorf("Expected brightness around 1 for white, got: %f", brightness)
-				}
-			}
+// 比较不同工作协程数下的转换耗时
+func BenchmarkConvert(b *testing.B) {
+	counts := []int{1, 2, 4}
+	if n := runtime.GOMAXPROCS(0); n > 4 {
+		counts = append(counts, n)
+	}
+	for _, mode := range []string{"brightness", "structure"} {
+		for _, workers := range counts {
+			b.Run(fmt.Sprintf("%s/workers=%d", mode, workers), func(b *testing.B) {
+				benchmarkConvert(b, workers, mode)
+			})
 		}
-	})
+	}
 }
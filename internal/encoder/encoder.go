@@ -0,0 +1,163 @@
+package encoder
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// Options 编码参数，零值使用各格式的默认设置
+type Options struct {
+	// Quality JPEG 质量，1-100，0 使用 jpeg.DefaultQuality
+	Quality int
+	// Compression PNG 和 TIFF 的压缩级别：default、none、fast 或 best
+	Compression string
+}
+
+// Validate 检查参数是否有效
+func (o Options) Validate() error {
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("invalid JPEG quality: %d", o.Quality)
+	}
+	if _, err := pngCompression(o.Compression); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Format 栅格图像格式
+type Format struct {
+	// Name 格式名称
+	Name string
+	// Extensions 文件扩展名，包含点号
+	Extensions []string
+	// Alpha 格式是否保留透明度
+	Alpha bool
+	// Lossless 格式是否无损
+	Lossless bool
+
+	encode func(w io.Writer, img image.Image, opts Options) error
+}
+
+// Encode 按 opts 将 img 编码写入 w
+func (f Format) Encode(w io.Writer, img image.Image, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return f.encode(w, img, opts)
+}
+
+// formats 支持的格式
+var formats = []Format{
+	{
+		Name:       "jpeg",
+		Extensions: []string{".jpg", ".jpeg"},
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			quality := opts.Quality
+			if quality == 0 {
+				quality = jpeg.DefaultQuality
+			}
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+	},
+	{
+		Name:       "png",
+		Extensions: []string{".png"},
+		Alpha:      true,
+		Lossless:   true,
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			level, _ := pngCompression(opts.Compression)
+			enc := &png.Encoder{CompressionLevel: level}
+			return enc.Encode(w, img)
+		},
+	},
+	{
+		Name:       "gif",
+		Extensions: []string{".gif"},
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			return gif.Encode(w, img, nil)
+		},
+	},
+	{
+		Name:       "bmp",
+		Extensions: []string{".bmp"},
+		Lossless:   true,
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			return bmp.Encode(w, img)
+		},
+	},
+	{
+		Name:       "tiff",
+		Extensions: []string{".tif", ".tiff"},
+		Alpha:      true,
+		Lossless:   true,
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			compression := tiff.Deflate
+			if opts.Compression == "none" {
+				compression = tiff.Uncompressed
+			}
+			return tiff.Encode(w, img, &tiff.Options{Compression: compression, Predictor: true})
+		},
+	},
+	{
+		Name:       "webp",
+		Extensions: []string{".webp"},
+		Alpha:      true,
+		Lossless:   true,
+		encode: func(w io.Writer, img image.Image, opts Options) error {
+			return encodeWebP(w, img)
+		},
+	},
+}
+
+// All 返回所有支持的格式
+func All() []Format {
+	return append([]Format(nil), formats...)
+}
+
+// Lookup 按名称查找格式
+func Lookup(name string) (Format, bool) {
+	for _, f := range formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// ForPath 按文件扩展名查找格式，扩展名不区分大小写
+func ForPath(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// pngCompression 将压缩级别名称转换为 png.CompressionLevel
+func pngCompression(name string) (png.CompressionLevel, error) {
+	switch name {
+	case "", "default":
+		return png.DefaultCompression, nil
+	case "none":
+		return png.NoCompression, nil
+	case "fast":
+		return png.BestSpeed, nil
+	case "best":
+		return png.BestCompression, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression level: %s", name)
+	}
+}
@@ -0,0 +1,115 @@
+package encoder
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	_ "golang.org/x/image/webp"
+)
+
+// testImage 生成左半透明、右半不透明的渐变图像
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			a := uint8(255)
+			if x < 8 {
+				a = 0
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 16), uint8(y * 32), 128, a})
+		}
+	}
+	return img
+}
+
+// 测试每种格式编码后都能解码回同尺寸的图像，无损格式像素不变
+func TestRoundTrip(t *testing.T) {
+	src := testImage()
+	for _, f := range All() {
+		var buf bytes.Buffer
+		if err := f.Encode(&buf, src, Options{}); err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		img, name, err := image.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", f.Name, err)
+		}
+		if name != f.Name {
+			t.Errorf("%s: decoded as %s", f.Name, name)
+		}
+		if img.Bounds() != src.Bounds() {
+			t.Errorf("%s: bounds %v, want %v", f.Name, img.Bounds(), src.Bounds())
+		}
+		if !f.Lossless || !f.Alpha {
+			continue
+		}
+		for _, p := range []image.Point{{0, 0}, {12, 5}} {
+			got := color.NRGBAModel.Convert(img.At(p.X, p.Y))
+			if want := src.NRGBAAt(p.X, p.Y); got != want {
+				t.Errorf("%s: pixel %v = %v, want %v", f.Name, p, got, want)
+			}
+		}
+	}
+}
+
+// 测试 JPEG 质量影响输出大小
+func TestJPEGQuality(t *testing.T) {
+	f, _ := Lookup("jpeg")
+	size := func(q int) int {
+		var buf bytes.Buffer
+		if err := f.Encode(&buf, testImage(), Options{Quality: q}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Len()
+	}
+	if low, high := size(10), size(95); low >= high {
+		t.Errorf("quality 10 gave %d bytes, quality 95 gave %d", low, high)
+	}
+}
+
+// 测试 PNG 不同压缩级别都能编码，none 输出最大
+func TestPNGCompression(t *testing.T) {
+	f, _ := Lookup("png")
+	sizes := map[string]int{}
+	for _, level := range []string{"", "default", "none", "fast", "best"} {
+		var buf bytes.Buffer
+		if err := f.Encode(&buf, testImage(), Options{Compression: level}); err != nil {
+			t.Fatalf("%q: %v", level, err)
+		}
+		sizes[level] = buf.Len()
+	}
+	if sizes["none"] <= sizes["best"] {
+		t.Errorf("none gave %d bytes, best gave %d", sizes["none"], sizes["best"])
+	}
+}
+
+// 测试无效参数
+func TestValidate(t *testing.T) {
+	for _, opts := range []Options{{Quality: -1}, {Quality: 101}, {Compression: "max"}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+	f, _ := Lookup("png")
+	if err := f.Encode(&bytes.Buffer{}, testImage(), Options{Compression: "max"}); err == nil {
+		t.Error("expected Encode to validate options")
+	}
+}
+
+// 测试按名称和扩展名查找格式
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("webm"); ok {
+		t.Error("webm should not be found")
+	}
+	for path, want := range map[string]string{"a.JPG": "jpeg", "b.png": "png", "c.tif": "tiff", "d.bmp": "bmp", "e.gif": "gif", "f.WebP": "webp"} {
+		f, ok := ForPath(path)
+		if !ok || f.Name != want {
+			t.Errorf("ForPath(%q) = %q, %v; want %q", path, f.Name, ok, want)
+		}
+	}
+	if _, ok := ForPath("out.txt"); ok {
+		t.Error("ForPath(out.txt) should fail")
+	}
+}
@@ -0,0 +1,454 @@
+package encoder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// VP8L 无损 WebP 的常量
+const (
+	webpMaxSide       = 1 << 14
+	webpLiteralCodes  = 256
+	webpLengthCodes   = 24
+	webpDistanceCodes = 40
+	webpMinMatch      = 3
+	webpMaxMatch      = 4096
+	webpMaxCodeLength = 15
+	// webpSubtractGreen 减绿变换的类型编号
+	webpSubtractGreen = 2
+	// webpDistanceAbove 和 webpDistanceLeft 为指向上方和左侧像素的距离码
+	webpDistanceAbove = 1
+	webpDistanceLeft  = 2
+)
+
+// webpCodeLengthOrder 码长码的码长在码流中的顺序
+var webpCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP 将 img 编码为无损 WebP（VP8L）。
+// 使用减绿变换，并以指向左侧或上方像素的 LZ77 后向引用压缩重复像素。
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > webpMaxSide || height > webpMaxSide {
+		return fmt.Errorf("invalid WebP image size: %dx%d", width, height)
+	}
+	argb, alpha := webpPixels(img)
+
+	var bw bitWriter
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if alpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+	// 只有减绿一个变换
+	bw.write(1, 1)
+	bw.write(webpSubtractGreen, 2)
+	bw.write(0, 1)
+	// 不使用颜色缓存和元前缀码
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	tokens := webpLZ77(argb, width)
+	var hist [5][]int
+	for i, n := range []int{webpLiteralCodes + webpLengthCodes, 256, 256, 256, webpDistanceCodes} {
+		hist[i] = make([]int, n)
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			hist[0][t.pixel>>8&0xff]++
+			hist[1][t.pixel>>16&0xff]++
+			hist[2][t.pixel&0xff]++
+			hist[3][t.pixel>>24]++
+			continue
+		}
+		prefix, _, _ := webpPrefix(t.length)
+		hist[0][webpLiteralCodes+prefix]++
+		prefix, _, _ = webpPrefix(t.dist)
+		hist[4][prefix]++
+	}
+	var codes [5]prefixCode
+	for i := range hist {
+		codes[i] = bw.writePrefixCode(hist[i])
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(&bw, int(t.pixel>>8&0xff))
+			codes[1].write(&bw, int(t.pixel>>16&0xff))
+			codes[2].write(&bw, int(t.pixel&0xff))
+			codes[3].write(&bw, int(t.pixel>>24))
+			continue
+		}
+		prefix, extraBits, extra := webpPrefix(t.length)
+		codes[0].write(&bw, webpLiteralCodes+prefix)
+		bw.write(extra, extraBits)
+		prefix, extraBits, extra = webpPrefix(t.dist)
+		codes[4].write(&bw, prefix)
+		bw.write(extra, extraBits)
+	}
+	data := bw.bytes()
+
+	size := len(data) + len(data)&1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+size))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if len(data)&1 == 1 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// webpPixels 返回减绿变换后的 ARGB 像素，以及图像是否含有透明像素
+func webpPixels(img image.Image) ([]uint32, bool) {
+	b := img.Bounds()
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		nrgba = image.NewNRGBA(b)
+		draw.Draw(nrgba, b, img, b.Min, draw.Src)
+	}
+	argb := make([]uint32, 0, b.Dx()*b.Dy())
+	alpha := false
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := nrgba.Pix[nrgba.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			r, g, bl, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			if a != 255 {
+				alpha = true
+			}
+			argb = append(argb, uint32(a)<<24|uint32(r-g)<<16|uint32(g)<<8|uint32(bl-g))
+		}
+	}
+	return argb, alpha
+}
+
+// webpToken 字面像素，或 length 不为 0 时的后向引用
+type webpToken struct {
+	pixel  uint32
+	length int
+	dist   int
+}
+
+// webpLZ77 贪心地查找与左侧或上方像素相同的连续像素
+func webpLZ77(argb []uint32, width int) []webpToken {
+	var tokens []webpToken
+	for i := 0; i < len(argb); {
+		limit := minInt(webpMaxMatch, len(argb)-i)
+		length, dist := 0, 0
+		if i >= 1 {
+			length, dist = webpMatch(argb, i, 1, limit), webpDistanceLeft
+		}
+		if i >= width {
+			if n := webpMatch(argb, i, width, limit); n > length {
+				length, dist = n, webpDistanceAbove
+			}
+		}
+		if length >= webpMinMatch {
+			tokens = append(tokens, webpToken{length: length, dist: dist})
+			i += length
+			continue
+		}
+		tokens = append(tokens, webpToken{pixel: argb[i]})
+		i++
+	}
+	return tokens
+}
+
+// webpMatch 返回从 i 开始与 offset 之前的像素相同的像素数，最多 limit 个
+func webpMatch(argb []uint32, i, offset, limit int) int {
+	n := 0
+	for n < limit && argb[i+n] == argb[i+n-offset] {
+		n++
+	}
+	return n
+}
+
+// webpPrefix 将长度或距离码 v（v >= 1）编码为前缀码和额外位
+func webpPrefix(v int) (prefix int, extraBits uint, extra uint32) {
+	n := v - 1
+	if n < 4 {
+		return n, 0, 0
+	}
+	h := uint(0)
+	for n>>(h+1) != 0 {
+		h++
+	}
+	second := n >> (h - 1) & 1
+	extraBits = h - 1
+	return int(2*h) + second, extraBits, uint32(n & (1<<extraBits - 1))
+}
+
+// bitWriter 按 VP8L 的顺序从低位开始写入比特
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// write 写入 v 的低 n 位，n 不超过 32
+func (bw *bitWriter) write(v uint32, n uint) {
+	bw.acc |= uint64(v) << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+// bytes 补齐最后一个字节并返回写入的数据
+func (bw *bitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nbits = 0, 0
+	}
+	return bw.buf
+}
+
+// prefixCode 每个符号的码字，码字已按写入顺序反转
+type prefixCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+// write 写入 symbol 的码字
+func (c prefixCode) write(bw *bitWriter, symbol int) {
+	bw.write(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// writePrefixCode 根据直方图 counts 构造前缀码并写入码流。
+// 至多两个小于 256 的符号时使用简单码，否则写入码长。
+func (bw *bitWriter) writePrefixCode(counts []int) prefixCode {
+	var used []int
+	for s, n := range counts {
+		if n > 0 {
+			used = append(used, s)
+		}
+	}
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+		}
+		// 简单码中第 i 个符号的码字为 i，码长为符号数减一
+		c := prefixCode{codes: make([]uint32, len(counts)), lengths: make([]uint8, len(counts))}
+		for i, s := range used {
+			c.codes[s] = uint32(i)
+			c.lengths[s] = uint8(len(used) - 1)
+		}
+		return c
+	}
+
+	lengths := huffmanLengths(counts, webpMaxCodeLength)
+	bw.writeCodeLengths(lengths)
+	return canonicalCode(lengths)
+}
+
+// writeCodeLengths 用码长码写入 lengths，连续的零和重复码长使用游程编码
+func (bw *bitWriter) writeCodeLengths(lengths []uint8) {
+	// clToken 码长码的符号及其 bits 位额外位
+	type clToken struct {
+		symbol int
+		extra  uint32
+		bits   uint
+	}
+	var tokens []clToken
+	for i := 0; i < len(lengths); {
+		v := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == v {
+			run++
+		}
+		i += run
+		if v == 0 {
+			for run >= 11 {
+				n := minInt(run, 138)
+				tokens = append(tokens, clToken{18, uint32(n - 11), 7})
+				run -= n
+			}
+			if run >= 3 {
+				tokens = append(tokens, clToken{17, uint32(run - 3), 3})
+				run = 0
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, clToken{symbol: 0})
+			}
+			continue
+		}
+		// 先写入码长本身，之后的重复使用 16
+		tokens = append(tokens, clToken{symbol: int(v)})
+		run--
+		for run >= 3 {
+			n := minInt(run, 6)
+			tokens = append(tokens, clToken{16, uint32(n - 3), 2})
+			run -= n
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, clToken{symbol: int(v)})
+		}
+	}
+
+	counts := make([]int, len(webpCodeLengthOrder))
+	for _, t := range tokens {
+		counts[t.symbol]++
+	}
+	clLengths := huffmanLengths(counts, 7)
+	numCodes := 4
+	for i, s := range webpCodeLengthOrder {
+		if clLengths[s] > 0 && i+1 > numCodes {
+			numCodes = i + 1
+		}
+	}
+	bw.write(0, 1)
+	bw.write(uint32(numCodes-4), 4)
+	for _, s := range webpCodeLengthOrder[:numCodes] {
+		bw.write(uint32(clLengths[s]), 3)
+	}
+	// 不限制最大符号数，码长覆盖整个字母表
+	bw.write(0, 1)
+	code := canonicalCode(clLengths)
+	for _, t := range tokens {
+		code.write(bw, t.symbol)
+		bw.write(t.extra, t.bits)
+	}
+}
+
+// huffmanLengths 计算 counts 的霍夫曼码长，最长不超过 limit。
+// 超过 limit 时提高最小频数后重新构造，直到满足限制。
+func huffmanLengths(counts []int, limit int) []uint8 {
+	lengths := make([]uint8, len(counts))
+	type node struct {
+		weight      int
+		left, right int
+		symbol      int
+	}
+	var symbols []int
+	for s, n := range counts {
+		if n > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	switch len(symbols) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[symbols[0]] = 1
+		return lengths
+	}
+	for floor := 1; ; floor *= 2 {
+		nodes := make([]node, 0, 2*len(symbols))
+		for _, s := range symbols {
+			nodes = append(nodes, node{weight: maxInt(counts[s], floor), left: -1, right: -1, symbol: s})
+		}
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+		// 两个队列：按权重排好序的叶子和按生成顺序递增的内部节点
+		leaf, inner := 0, len(nodes)
+		pop := func() int {
+			if leaf < len(symbols) && (inner >= len(nodes) || nodes[leaf].weight <= nodes[inner].weight) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for k := 1; k < len(symbols); k++ {
+			a, b := pop(), pop()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, left: a, right: b, symbol: -1})
+		}
+		depths := make([]int, len(nodes))
+		fits := true
+		for i := len(nodes) - 1; i >= 0; i-- {
+			n := nodes[i]
+			if n.symbol >= 0 {
+				if depths[i] > limit {
+					fits = false
+				}
+				lengths[n.symbol] = uint8(depths[i])
+				continue
+			}
+			depths[n.left] = depths[i] + 1
+			depths[n.right] = depths[i] + 1
+		}
+		if fits {
+			return lengths
+		}
+	}
+}
+
+// canonicalCode 按码长分配规范霍夫曼码字，码长相同时符号小的码字小。
+// 只有一个符号时解码器不读取任何比特，码长记为 0。
+func canonicalCode(lengths []uint8) prefixCode {
+	c := prefixCode{codes: make([]uint32, len(lengths)), lengths: make([]uint8, len(lengths))}
+	var count [webpMaxCodeLength + 1]int
+	used := 0
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+			used++
+		}
+	}
+	if used <= 1 {
+		return c
+	}
+	var next [webpMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for l := 1; l <= webpMaxCodeLength; l++ {
+		code = (code + uint32(count[l-1])) << 1
+		next[l] = code
+	}
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		// 解码器从码字的最高位开始读取，因此按位反转后写入
+		v := next[l]
+		next[l]++
+		var rev uint32
+		for i := uint8(0); i < l; i++ {
+			rev = rev<<1 | v>>i&1
+		}
+		c.codes[s] = rev
+		c.lengths[s] = l
+	}
+	return c
+}
+
+// minInt 返回较小的整数
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt 返回较大的整数
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
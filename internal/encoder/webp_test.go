@@ -0,0 +1,132 @@
+package encoder
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// webpRoundTrip 编码 img 后用 golang.org/x/image/webp 解码
+func webpRoundTrip(t *testing.T, img image.Image) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	out, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return out
+}
+
+// assertSamePixels 比较两幅图像的未预乘颜色
+func assertSamePixels(t *testing.T, got, want image.Image) {
+	t.Helper()
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		t.Fatalf("size %v, want %v", gb.Size(), wb.Size())
+	}
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y))
+			if g != w {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+// 测试不同内容和尺寸的图像编码后像素不变
+func TestWebPLossless(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := func(w, h int, alpha bool) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		rng.Read(img.Pix)
+		if !alpha {
+			for i := 3; i < len(img.Pix); i += 4 {
+				img.Pix[i] = 255
+			}
+		}
+		return img
+	}
+	solid := image.NewNRGBA(image.Rect(0, 0, 300, 7))
+	draw.Draw(solid, solid.Bounds(), image.NewUniform(color.NRGBA{10, 200, 30, 255}), image.Point{}, draw.Src)
+	// 少量颜色组成的重复图案，产生大量后向引用
+	stripes := image.NewNRGBA(image.Rect(0, 0, 97, 61))
+	for y := 0; y < 61; y++ {
+		for x := 0; x < 97; x++ {
+			if (x/5+y/3)%3 == 0 {
+				stripes.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			} else {
+				stripes.SetNRGBA(x, y, color.NRGBA{uint8(y * 4), 0, uint8(x), 128})
+			}
+		}
+	}
+	cases := map[string]image.Image{
+		"1x1":         noise(1, 1, true),
+		"single row":  noise(37, 1, false),
+		"single col":  noise(1, 53, false),
+		"opaque odd":  noise(31, 17, false),
+		"alpha odd":   noise(45, 23, true),
+		"solid":       solid,
+		"stripes":     stripes,
+		"long runs":   image.NewNRGBA(image.Rect(0, 0, 5000, 3)),
+		"gradient":    testImage(),
+		"subimage":    noise(40, 30, true).SubImage(image.Rect(3, 5, 36, 29)),
+		"transparent": image.NewRGBA(image.Rect(0, 0, 9, 9)),
+	}
+	for name, img := range cases {
+		t.Run(name, func(t *testing.T) {
+			assertSamePixels(t, webpRoundTrip(t, img), img)
+		})
+	}
+}
+
+// 测试重复内容被压缩
+func TestWebPCompression(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > 1024 {
+		t.Errorf("blank 512x512 image encoded to %d bytes", buf.Len())
+	}
+}
+
+// 测试超出 VP8L 限制的尺寸
+func TestWebPInvalidSize(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 0, 5), image.Rect(0, 0, 1<<14+1, 1)} {
+		if err := encodeWebP(&bytes.Buffer{}, image.NewNRGBA(r)); err == nil {
+			t.Errorf("%v: expected error", r)
+		}
+	}
+}
+
+// 测试码长受限的霍夫曼码：斐波那契分布会产生超过 15 的码长
+func TestHuffmanLengthsLimit(t *testing.T) {
+	counts := make([]int, 30)
+	a, b := 1, 1
+	for i := range counts {
+		counts[i] = a
+		a, b = b, a+b
+	}
+	lengths := huffmanLengths(counts, webpMaxCodeLength)
+	kraft := 0.0
+	for s, l := range lengths {
+		if l == 0 || l > webpMaxCodeLength {
+			t.Fatalf("symbol %d has length %d", s, l)
+		}
+		kraft += 1 / float64(uint(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("Kraft sum %v, want 1", kraft)
+	}
+}
@@ -1,12 +1,17 @@
 package fonts
 
 import (
-    "fmt"
+    "os"
     "path/filepath"
+    "sync"
 
     "github.com/fogleman/gg"
+    "github.com/golang/freetype/truetype"
 )
 
+// parsedFonts 已解析的字体文件缓存，按路径索引
+var parsedFonts sync.Map
+
 // FontConfig 字体配置结构
 type FontConfig struct {
     Path string
@@ -30,15 +35,35 @@ func GetFontConfig(language string, scale float64) FontConfig {
     default:
         return FontConfig{
             Path: filepath.Join("fonts", "DejaVuSansMono-Bold.ttf"),
-            Size: baseSize * 2 * scale,
+            Size: 12 * scale,
         }
     }
 }
 
 // LoadFont 加载字体
 func LoadFont(ctx *gg.Context, cfg FontConfig) error {
-    if err := ctx.LoadFontFace(cfg.Path, cfg.Size); err != nil {
-        return fmt.Errorf("failed to load font %s: %v", cfg.Path, err)
+    face, err := LoadFace(cfg)
+    if err != nil {
+        return err
     }
+    ctx.SetFontFace(face)
     return nil
+}
+
+// parseFont 读取并解析字体文件，同一路径只解析一次
+// 解析结果可以并发共享，由它创建的 font.Face 则不能。
+func parseFont(path string) (*truetype.Font, error) {
+    if f, ok := parsedFonts.Load(path); ok {
+        return f.(*truetype.Font), nil
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    f, err := truetype.Parse(data)
+    if err != nil {
+        return nil, err
+    }
+    parsedFonts.Store(path, f)
+    return f, nil
 } 
\ No newline at end of file
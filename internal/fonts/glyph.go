@@ -0,0 +1,79 @@
+package fonts
+
+import (
+	"fmt"
+	"image"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// LoadFace 加载字体文件为 font.Face，每次调用返回新的 Face，可分别在不同协程中使用
+func LoadFace(cfg FontConfig) (font.Face, error) {
+	f, err := parseFont(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font %s: %v", cfg.Path, err)
+	}
+	return truetype.NewFace(f, &truetype.Options{Size: cfg.Size}), nil
+}
+
+// LoadTrueType 加载字体文件为 truetype.Font，用于读取字形轮廓和字体名称，结果可以并发共享
+func LoadTrueType(cfg FontConfig) (*truetype.Font, error) {
+	f, err := parseFont(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font %s: %v", cfg.Path, err)
+	}
+	return f, nil
+}
+
+// CellSize 返回能容纳 chars 中所有字形的单元格像素尺寸
+func CellSize(face font.Face, chars []rune) (width, height int) {
+	var maxAdvance fixed.Int26_6
+	for _, r := range chars {
+		if advance, ok := face.GlyphAdvance(r); ok && advance > maxAdvance {
+			maxAdvance = advance
+		}
+	}
+	metrics := face.Metrics()
+	return maxAdvance.Ceil(), (metrics.Ascent + metrics.Descent).Ceil()
+}
+
+// RasterizeGlyph 将字符渲染为 width x height 的覆盖率位图，字形在单元格内居中
+// 空白字符和字体中不存在的字符返回全空位图。
+func RasterizeGlyph(face font.Face, r rune, width, height int) *image.Alpha {
+	dst := image.NewAlpha(image.Rect(0, 0, width, height))
+	if unicode.IsSpace(r) {
+		return dst
+	}
+	advance, ok := face.GlyphAdvance(r)
+	if !ok {
+		return dst
+	}
+
+	metrics := face.Metrics()
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.Opaque,
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: (fixed.I(width) - advance) / 2,
+			Y: (fixed.I(height) + metrics.Ascent - metrics.Descent) / 2,
+		},
+	}
+	drawer.DrawString(string(r))
+	return dst
+}
+
+// Coverage 计算位图的墨迹覆盖率，范围 [0, 1]
+func Coverage(img *image.Alpha) float64 {
+	if len(img.Pix) == 0 {
+		return 0
+	}
+	var sum int
+	for _, a := range img.Pix {
+		sum += int(a)
+	}
+	return float64(sum) / float64(255*len(img.Pix))
+}
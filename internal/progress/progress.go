@@ -0,0 +1,207 @@
+package progress
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 转换过程中的阶段名称
+const (
+	StageDecode  = "decode"
+	StageExtract = "extract"
+	StageSelect  = "select"
+	StageDraw    = "draw"
+	StageFrames  = "frames"
+	StageEncode  = "encode"
+	StageWrite   = "write"
+)
+
+// Event 进度事件
+// Total 为 0 表示总量未知，此时 ETA 也为 0。
+type Event struct {
+	Stage   string
+	Done    int
+	Total   int
+	Elapsed time.Duration
+	ETA     time.Duration
+	Bytes   int64
+}
+
+// Reporter 接收进度事件，可能被多个协程同时调用
+type Reporter interface {
+	Report(Event)
+}
+
+// ReporterFunc 将函数适配为 Reporter
+type ReporterFunc func(Event)
+
+// Report 实现 Reporter 接口
+func (f ReporterFunc) Report(e Event) {
+	f(e)
+}
+
+type reporterKey struct{}
+type stageKey struct{}
+
+// WithReporter 返回携带 r 的 ctx，转换函数和工作池从中取得进度报告器
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+// FromContext 返回 ctx 携带的进度报告器，没有时返回 nil
+func FromContext(ctx context.Context) Reporter {
+	r, _ := ctx.Value(reporterKey{}).(Reporter)
+	return r
+}
+
+// WithStage 返回标记了阶段名称的 ctx，工作池按该阶段报告任务的完成情况
+func WithStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, stageKey{}, stage)
+}
+
+// StageFrom 返回 ctx 标记的阶段名称，没有时返回空字符串
+func StageFrom(ctx context.Context) string {
+	stage, _ := ctx.Value(stageKey{}).(string)
+	return stage
+}
+
+// Tracker 跟踪一个阶段的进度，计算耗时和预计剩余时间并发送事件
+// ctx 没有携带报告器时 Tracker 的所有方法都不做任何事。
+type Tracker struct {
+	mu       sync.Mutex
+	reporter Reporter
+	stage    string
+	total    int
+	done     int
+	bytes    int64
+	start    time.Time
+	reported time.Time
+	now      func() time.Time
+}
+
+// bytesInterval 仅字节数变化时两次事件之间的最小间隔，避免按写入缓冲区大小刷屏
+const bytesInterval = 100 * time.Millisecond
+
+// NewTracker 开始跟踪 ctx 中的一个阶段并立即发送初始事件
+func NewTracker(ctx context.Context, stage string, total int) *Tracker {
+	t := &Tracker{
+		reporter: FromContext(ctx),
+		stage:    stage,
+		total:    total,
+		now:      time.Now,
+	}
+	t.start = t.now()
+	t.report()
+	return t
+}
+
+// Add 记录完成了 n 个单位
+func (t *Tracker) Add(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done += n
+	t.report()
+}
+
+// AddBytes 记录写出了 n 个字节
+func (t *Tracker) AddBytes(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bytes += n
+	t.reportBytes()
+}
+
+// SetBytes 将已写出的字节数设为 n，用于轮询外部程序写入的文件大小
+func (t *Tracker) SetBytes(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bytes = n
+	t.reportBytes()
+}
+
+// Finish 将阶段标记为完成；总量未知时以已完成的数量作为总量
+func (t *Tracker) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.total == 0 {
+		t.total = t.done
+	}
+	t.done = t.total
+	t.report()
+}
+
+// reportBytes 距上次事件超过 bytesInterval 时发送当前状态，调用方需持有锁
+func (t *Tracker) reportBytes() {
+	if t.reporter != nil && t.now().Sub(t.reported) >= bytesInterval {
+		t.report()
+	}
+}
+
+// report 发送当前状态，调用方需持有锁
+func (t *Tracker) report() {
+	if t.reporter == nil {
+		return
+	}
+	now := t.now()
+	t.reported = now
+	e := Event{
+		Stage:   t.stage,
+		Done:    t.done,
+		Total:   t.total,
+		Elapsed: now.Sub(t.start),
+		Bytes:   t.bytes,
+	}
+	if t.total > 0 && t.done > 0 && t.done < t.total {
+		e.ETA = time.Duration(float64(e.Elapsed) / float64(t.done) * float64(t.total-t.done))
+	}
+	t.reporter.Report(e)
+}
+
+// Writer 返回包装了 w 的 io.Writer，写出的字节数计入 t
+func (t *Tracker) Writer(w io.Writer) io.Writer {
+	return &countingWriter{w: w, t: t}
+}
+
+// WatchFile 每隔 interval 读取一次 path 的大小作为已写出的字节数，
+// 用于跟踪 ffmpeg 等外部程序的输出；调用返回的函数停止轮询。
+func (t *Tracker) WatchFile(path string, interval time.Duration) (stop func()) {
+	if t.reporter == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if info, err := os.Stat(path); err == nil {
+					t.SetBytes(info.Size())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// countingWriter 统计写出字节数的 io.Writer
+type countingWriter struct {
+	w io.Writer
+	t *Tracker
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.t.AddBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,192 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock 每次调用前进固定的时间
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.t = c.t.Add(c.step)
+	return c.t
+}
+
+// recorder 记录收到的事件
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Report(e Event) {
+	r.events = append(r.events, e)
+}
+
+// 测试 Tracker 按已完成的比例估计剩余时间
+func TestTrackerETA(t *testing.T) {
+	rec := &recorder{}
+	clock := &fakeClock{step: time.Second}
+	tracker := NewTracker(WithReporter(context.Background(), rec), StageFrames, 4)
+	tracker.now = clock.now
+	tracker.start = clock.t
+
+	tracker.Add(1)
+	e := rec.events[len(rec.events)-1]
+	if e.Done != 1 || e.Elapsed != time.Second || e.ETA != 3*time.Second {
+		t.Errorf("unexpected event after one frame: %+v", e)
+	}
+
+	tracker.Add(3)
+	e = rec.events[len(rec.events)-1]
+	if e.Done != 4 || e.ETA != 0 {
+		t.Errorf("expected completed event without ETA, got %+v", e)
+	}
+}
+
+// 测试总量未知的阶段完成后以已完成数量作为总量
+func TestTrackerFinishUnknownTotal(t *testing.T) {
+	rec := &recorder{}
+	tracker := NewTracker(WithReporter(context.Background(), rec), StageExtract, 0)
+	tracker.Add(2)
+	tracker.Finish()
+	e := rec.events[len(rec.events)-1]
+	if e.Done != 2 || e.Total != 2 {
+		t.Errorf("expected 2/2, got %+v", e)
+	}
+}
+
+// 测试仅字节数变化的事件被节流，写出的字节数最终准确
+func TestTrackerWriter(t *testing.T) {
+	rec := &recorder{}
+	tracker := NewTracker(WithReporter(context.Background(), rec), StageWrite, 1)
+	var buf bytes.Buffer
+	w := tracker.Writer(&buf)
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("0123456789"))
+	}
+	tracker.Finish()
+
+	if len(rec.events) > 10 {
+		t.Errorf("expected byte updates to be throttled, got %d events", len(rec.events))
+	}
+	if e := rec.events[len(rec.events)-1]; e.Bytes != 1000 || e.Done != 1 {
+		t.Errorf("expected 1000 bytes written, got %+v", e)
+	}
+}
+
+// 测试没有报告器时 Tracker 不做任何事
+func TestTrackerWithoutReporter(t *testing.T) {
+	tracker := NewTracker(context.Background(), StageDraw, 3)
+	tracker.Add(1)
+	tracker.Finish()
+	tracker.WatchFile("does-not-exist", time.Millisecond)()
+}
+
+// 测试 JSON 行的字段和单位
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	j := NewJSON(&buf)
+	j.Report(Event{Stage: StageFrames, Done: 3, Total: 10, Elapsed: 1500 * time.Millisecond, ETA: 3500 * time.Millisecond, Bytes: 42})
+	j.Report(Event{Stage: StageEncode})
+	j.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	expected := map[string]interface{}{"stage": "frames", "done": 3.0, "total": 10.0, "elapsed_ms": 1500.0, "eta_ms": 3500.0, "bytes": 42.0}
+	for key, value := range expected {
+		if got[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, got[key])
+		}
+	}
+}
+
+// 测试日志行在阶段变化和完成时输出，期间按间隔节流，Close 输出最后一个被节流的事件
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLog(&buf, time.Minute)
+	l.logger.SetFlags(0)
+	clock := &fakeClock{step: time.Second}
+	l.now = clock.now
+
+	for i := 0; i <= 5; i++ {
+		l.Report(Event{Stage: StageFrames, Done: i, Total: 10})
+	}
+	l.Report(Event{Stage: StageEncode})
+	l.Report(Event{Stage: StageEncode, Bytes: 2048})
+	l.Close()
+
+	expected := []string{
+		"frames 0/10 (0%) elapsed 0s",
+		"frames 5/10 (50%) elapsed 0s",
+		"encode 0 elapsed 0s",
+		"encode 0 elapsed 0s written 2.0 KiB",
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected lines:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+// 测试进度条在同一行刷新，阶段变化和 Close 时换行
+func TestBar(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBar(&buf)
+	b.width = 10
+	clock := &fakeClock{step: time.Second}
+	b.now = clock.now
+
+	b.Report(Event{Stage: StageFrames, Done: 5, Total: 10, ETA: 2 * time.Second})
+	b.Report(Event{Stage: StageFrames, Done: 10, Total: 10, Bytes: 1 << 20})
+	b.Report(Event{Stage: StageEncode})
+	b.Close()
+
+	expected := "\rframes   [#####-----] 5/10  50%  0s  ETA 2s" +
+		"\rframes   [##########] 10/10 100%  0s  1.0 MiB\n" +
+		"\rencode   0  0s\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+// 测试进度模式的解析
+func TestNew(t *testing.T) {
+	for _, mode := range []string{"auto", "bar", "log", "json"} {
+		if r, err := New(mode, nil); err != nil || r == nil {
+			t.Errorf("mode %s: expected renderer, got %v, %v", mode, r, err)
+		}
+	}
+	if r, err := New("none", nil); err != nil || r != nil {
+		t.Errorf("mode none: expected nil renderer, got %v, %v", r, err)
+	}
+	if _, err := New("fancy", nil); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+// 测试字节数的格式化
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:     "512 B",
+		1536:    "1.5 KiB",
+		3 << 20: "3.0 MiB",
+		5 << 30: "5.0 GiB",
+	}
+	for n, expected := range tests {
+		if got := formatBytes(n); got != expected {
+			t.Errorf("formatBytes(%d): expected %s, got %s", n, expected, got)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Renderer 将进度事件输出给用户或其他程序，转换结束后需要调用 Close
+type Renderer interface {
+	Reporter
+	Close()
+}
+
+// New 按模式创建输出到 out 的 Renderer，模式为 none 时返回 nil。
+// auto 在 out 是终端时显示进度条，否则定期输出日志行。
+func New(mode string, out *os.File) (Renderer, error) {
+	switch mode {
+	case "", "auto":
+		if IsTerminal(out) {
+			return NewBar(out), nil
+		}
+		return NewLog(out, 2*time.Second), nil
+	case "bar":
+		return NewBar(out), nil
+	case "log":
+		return NewLog(out, 2*time.Second), nil
+	case "json":
+		return NewJSON(out), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported progress mode: %s", mode)
+	}
+}
+
+// IsTerminal 判断 f 是否连接到终端
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Bar 在终端的同一行上刷新进度条，阶段变化时换行
+type Bar struct {
+	mu       sync.Mutex
+	w        io.Writer
+	width    int
+	interval time.Duration
+	stage    string
+	last     time.Time
+	lineLen  int
+	now      func() time.Time
+}
+
+// NewBar 创建输出到 w 的进度条
+func NewBar(w io.Writer) *Bar {
+	return &Bar{w: w, width: 30, interval: 100 * time.Millisecond, now: time.Now}
+}
+
+// Report 实现 Reporter 接口，同一阶段内的刷新间隔不小于 100 毫秒
+func (b *Bar) Report(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if e.Stage != b.stage {
+		if b.stage != "" {
+			fmt.Fprintln(b.w)
+		}
+		b.stage = e.Stage
+		b.lineLen = 0
+	} else if !complete(e) && now.Sub(b.last) < b.interval {
+		return
+	}
+	b.last = now
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "%-8s ", e.Stage)
+	if e.Total > 0 {
+		filled := b.width * e.Done / e.Total
+		fmt.Fprintf(&line, "[%s%s] %d/%d %3d%%", strings.Repeat("#", filled), strings.Repeat("-", b.width-filled),
+			e.Done, e.Total, 100*e.Done/e.Total)
+	} else {
+		fmt.Fprintf(&line, "%d", e.Done)
+	}
+	fmt.Fprintf(&line, "  %s", formatDuration(e.Elapsed))
+	if e.ETA > 0 {
+		fmt.Fprintf(&line, "  ETA %s", formatDuration(e.ETA))
+	}
+	if e.Bytes > 0 {
+		fmt.Fprintf(&line, "  %s", formatBytes(e.Bytes))
+	}
+
+	// 用空格覆盖上一次输出中更长的部分
+	text := line.String()
+	padding := b.lineLen - len(text)
+	b.lineLen = len(text)
+	if padding > 0 {
+		text += strings.Repeat(" ", padding)
+	}
+	fmt.Fprint(b.w, "\r"+text)
+}
+
+// Close 结束进度条所在的行
+func (b *Bar) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stage != "" {
+		fmt.Fprintln(b.w)
+		b.stage = ""
+	}
+}
+
+// Log 定期输出进度日志行，适用于输出不是终端的情况
+// 每个阶段开始和完成时各输出一行，期间每隔 interval 最多输出一行。
+type Log struct {
+	mu       sync.Mutex
+	logger   *log.Logger
+	interval time.Duration
+	stage    string
+	last     time.Time
+	pending  *Event
+	now      func() time.Time
+}
+
+// NewLog 创建输出到 w 的进度日志
+func NewLog(w io.Writer, interval time.Duration) *Log {
+	return &Log{logger: log.New(w, "", log.LstdFlags), interval: interval, now: time.Now}
+}
+
+// Report 实现 Reporter 接口
+func (l *Log) Report(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if e.Stage != l.stage {
+		l.flush()
+		l.stage = e.Stage
+	} else if !complete(e) && now.Sub(l.last) < l.interval {
+		l.pending = &e
+		return
+	}
+	l.last = now
+	l.pending = nil
+	l.logger.Print(formatEvent(e))
+}
+
+// Close 输出被节流而尚未输出的最后一个事件
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush()
+}
+
+// flush 输出尚未输出的事件，调用方需持有锁
+func (l *Log) flush() {
+	if l.pending != nil {
+		l.logger.Print(formatEvent(*l.pending))
+		l.pending = nil
+	}
+}
+
+// JSON 每个事件输出一行 JSON，供包装程序解析
+type JSON struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// jsonEvent JSON 行的格式，时间单位为毫秒
+type jsonEvent struct {
+	Stage     string `json:"stage"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	EtaMs     int64  `json:"eta_ms"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// NewJSON 创建输出到 w 的 JSON 行报告器
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{enc: json.NewEncoder(w)}
+}
+
+// Report 实现 Reporter 接口
+func (j *JSON) Report(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.enc.Encode(jsonEvent{
+		Stage:     e.Stage,
+		Done:      e.Done,
+		Total:     e.Total,
+		ElapsedMs: e.Elapsed.Milliseconds(),
+		EtaMs:     e.ETA.Milliseconds(),
+		Bytes:     e.Bytes,
+	})
+}
+
+// Close 实现 Renderer 接口，JSON 行没有需要结束的输出
+func (j *JSON) Close() {}
+
+// complete 判断事件是否表示阶段已完成
+func complete(e Event) bool {
+	return e.Total > 0 && e.Done >= e.Total
+}
+
+// formatEvent 将事件格式化为一行文本
+func formatEvent(e Event) string {
+	var line strings.Builder
+	line.WriteString(e.Stage)
+	if e.Total > 0 {
+		fmt.Fprintf(&line, " %d/%d (%d%%)", e.Done, e.Total, 100*e.Done/e.Total)
+	} else {
+		fmt.Fprintf(&line, " %d", e.Done)
+	}
+	fmt.Fprintf(&line, " elapsed %s", formatDuration(e.Elapsed))
+	if e.ETA > 0 {
+		fmt.Fprintf(&line, " eta %s", formatDuration(e.ETA))
+	}
+	if e.Bytes > 0 {
+		fmt.Fprintf(&line, " written %s", formatBytes(e.Bytes))
+	}
+	return line.String()
+}
+
+// formatDuration 一分钟以内精确到 0.1 秒，否则精确到秒
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// formatBytes 以二进制单位格式化字节数
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exp])
+}
@@ -3,11 +3,11 @@ package utils
 import (
     "fmt"
     "image"
-    "image/jpeg"
-    "image/png"
     "os"
     "path/filepath"
     "strings"
+
+    "github.com/hai119/Go-ASCII-generator/internal/encoder"
 )
 
 // IsSupportedImageFormat 检查是否支持的图像格式
@@ -30,15 +30,11 @@ func SaveImage(img image.Image, filename string) error {
     }
     defer file.Close()
 
-    ext := strings.ToLower(filepath.Ext(filename))
-    switch ext {
-    case ".jpg", ".jpeg":
-        return jpeg.Encode(file, img, nil)
-    case ".png":
-        return png.Encode(file, img)
-    default:
-        return fmt.Errorf("unsupported image format: %s", ext)
+    format, ok := encoder.ForPath(filename)
+    if !ok {
+        return fmt.Errorf("unsupported image format: %s", strings.ToLower(filepath.Ext(filename)))
     }
+    return format.Encode(file, img, encoder.Options{})
 }
 
 // EnsureDir ��保目录存在
@@ -53,4 +49,60 @@ func GetFileSize(filename string) (int64, error) {
         return 0, err
     }
     return info.Size(), nil
-} 
\ No newline at end of file
+} 
+
+// AtomicFile 原子写入的文件
+// 内容先写入目标目录下的临时文件，Commit 时重命名为目标文件；
+// 未提交就关闭时删除临时文件，中断或出错时不会留下写了一半的输出。
+type AtomicFile struct {
+    *os.File
+    path      string
+    committed bool
+}
+
+// CreateAtomic 在 path 所在目录创建临时文件，临时文件保留原扩展名，便于按扩展名识别格式的外部程序写入
+func CreateAtomic(path string) (*AtomicFile, error) {
+    dir, base := filepath.Split(path)
+    if dir == "" {
+        dir = "."
+    }
+    ext := filepath.Ext(base)
+    file, err := os.CreateTemp(dir, "."+strings.TrimSuffix(base, ext)+".tmp-*"+ext)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create temp file: %w", err)
+    }
+    // 临时文件默认只有所有者可读写，改为与 os.Create 创建的文件相近的权限
+    if err := file.Chmod(0644); err != nil {
+        file.Close()
+        os.Remove(file.Name())
+        return nil, fmt.Errorf("failed to set temp file mode: %w", err)
+    }
+    return &AtomicFile{File: file, path: path}, nil
+}
+
+// Commit 关闭临时文件并重命名为目标文件
+func (f *AtomicFile) Commit() error {
+    if f.committed {
+        return nil
+    }
+    if err := f.File.Close(); err != nil {
+        os.Remove(f.Name())
+        return fmt.Errorf("failed to close temp file: %w", err)
+    }
+    if err := os.Rename(f.Name(), f.path); err != nil {
+        os.Remove(f.Name())
+        return fmt.Errorf("failed to rename temp file: %w", err)
+    }
+    f.committed = true
+    return nil
+}
+
+// Close 放弃未提交的内容并删除临时文件，已提交时不做任何操作
+func (f *AtomicFile) Close() error {
+    if f.committed {
+        return nil
+    }
+    f.committed = true
+    f.File.Close()
+    return os.Remove(f.Name())
+}
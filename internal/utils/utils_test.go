@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hai119/Go-ASCII-generator/internal/utils"
@@ -90,3 +91,40 @@ func TestGetFileSize(t *testing.T) {
 		t.Errorf("GetFileSize(%s) = %d; want %d", filename, size, len(content))
 	}
 }
+
+func TestCreateAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.txt")
+
+	// 未提交时关闭不会留下任何文件
+	f, err := utils.CreateAtomic(path)
+	if err != nil {
+		t.Fatalf("CreateAtomic failed: %v", err)
+	}
+	f.WriteString("partial")
+	f.Close()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no files after abort, got %d", len(entries))
+	}
+
+	// 提交后目标文件包含完整内容，之后的 Close 不会删除它
+	f, err = utils.CreateAtomic(path)
+	if err != nil {
+		t.Fatalf("CreateAtomic failed: %v", err)
+	}
+	f.WriteString("complete")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to exist before commit", path)
+	}
+	if err := f.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	f.Close()
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "complete" {
+		t.Errorf("expected committed content, got %q (%v)", content, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the committed file, got %d entries", len(entries))
+	}
+}
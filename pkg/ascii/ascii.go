@@ -0,0 +1,259 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/hai119/Go-ASCII-generator/internal/converter"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// Grid is a converted image: Rows x Cols cells in row-major order
+type Grid = converter.Grid
+
+// Cell is one character of a Grid. A zero BG alpha means the cell has no
+// background of its own.
+type Cell = converter.Cell
+
+// Renderer writes a Grid in some format
+type Renderer = converter.Renderer
+
+// RenderOptions are passed to the constructor of a format's Renderer: the font,
+// the background color, the single color for WithMono, the worker pool and the
+// image encoding settings
+type RenderOptions = converter.RenderOptions
+
+// Format names an output format
+type Format string
+
+// Built-in output formats
+const (
+	// Text is plain text, or ANSI truecolor text when cells have backgrounds
+	Text Format = "text"
+	// ANSI is text colored with ANSI escape sequences; see WithANSIColors
+	ANSI Format = "ansi"
+	// ANS is BBS-style ANSI art: CP437 text with 16 iCE colors and a SAUCE
+	// record; see WithSAUCE and the "shade" charset of WithCharMode
+	ANS Format = "ans"
+	// XBin is CP437 text with a 16-color palette chosen from the image
+	XBin Format = "xbin"
+	// HTML is a page, or a fragment with WithHTML, holding the text in a <pre> element
+	HTML Format = "html"
+	// SVG is a vector image with the same size as the source image; see WithSVGFont
+	SVG Format = "svg"
+	// JSON is the grid with its charset, font and settings; see ReadGrid
+	JSON Format = "json"
+	// GridBinary holds the same data as JSON in a compact binary encoding
+	GridBinary Format = "grid"
+	// JPEG is an image with the same size as the source image; see WithJPEGQuality
+	JPEG Format = "jpeg"
+	// PNG is a lossless image that supports WithTransparent and WithPNGCompression
+	PNG Format = "png"
+	// GIF is a paletted image; ConvertVideo writes an animated GIF
+	GIF Format = "gif"
+	// BMP is an uncompressed image
+	BMP Format = "bmp"
+	// TIFF is a lossless image that supports WithTransparent
+	TIFF Format = "tiff"
+	// WebP is a lossless image that supports WithTransparent
+	WebP Format = "webp"
+	// MP4 is an H.264 video; only ConvertVideo supports it
+	MP4 Format = "mp4"
+)
+
+// Convert analyzes img into a Grid
+func Convert(ctx context.Context, img image.Image, opts ...Option) (*Grid, error) {
+	o := newOptions(opts)
+	p, err := converter.NewPipeline(&o.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	return p.Analyze(o.context(ctx), img)
+}
+
+// Render writes grid to w in the given format
+func Render(w io.Writer, grid *Grid, format Format, opts ...Option) error {
+	return RenderContext(context.Background(), w, grid, format, opts...)
+}
+
+// RenderContext is like Render but stops when ctx is canceled
+func RenderContext(ctx context.Context, w io.Writer, grid *Grid, format Format, opts ...Option) error {
+	o := newOptions(opts)
+	p, err := converter.NewPipeline(&o.cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	renderer, err := o.renderer(p, format)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(o.context(ctx), w, grid)
+}
+
+// ReadGrid reads a grid written in the JSON or GridBinary format, detecting
+// which from its header, so it can be passed to Render in another format
+func ReadGrid(r io.Reader) (*Grid, error) {
+	file, err := converter.ReadGridFile(r)
+	if err != nil {
+		return nil, err
+	}
+	return file.Grid()
+}
+
+// ReadText parses plain text, ANSI-colored text or an ANS file into a Grid that
+// can be rendered as an image. ANS files are recognized by their SAUCE record
+// or by not being valid UTF-8. Cells are sized to fit the characters in the
+// WithFont font unless WithCellSize is given.
+func ReadText(r io.Reader, opts ...Option) (*Grid, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text: %v", err)
+	}
+	o := newOptions(opts)
+	p, err := converter.NewPipeline(&o.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	return p.ParseText(data, false)
+}
+
+// ConvertImage decodes an image from r, converts it and writes it to w in
+// the given format. Any format registered with the image package can be read.
+func ConvertImage(ctx context.Context, r io.Reader, w io.Writer, format Format, opts ...Option) error {
+	o := newOptions(opts)
+	p, err := converter.NewPipeline(&o.cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	renderer, err := o.renderer(p, format)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %v", err)
+	}
+	ctx = o.context(ctx)
+	grid, err := p.Analyze(ctx, img)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(ctx, w, grid)
+}
+
+// ConvertVideo decodes a video from r with ffmpeg, converts WithFPS frames
+// per second and writes the result to w. Text output separates frames with
+// "Frame N:" headers; MP4 output is a fragmented MP4 that can be streamed;
+// GIF output is an animated GIF. Animated GIF input is decoded and GIF output
+// encoded without ffmpeg, so GIF to GIF conversion does not need it at all.
+func ConvertVideo(ctx context.Context, r io.Reader, w io.Writer, format Format, opts ...Option) error {
+	o := newOptions(opts)
+	p, err := converter.NewPipeline(&o.cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	ctx = o.context(ctx)
+
+	switch format {
+	case Text:
+		renderer := p.TextRenderer()
+		return converter.DecodeVideo(ctx, r, o.fps, func(index int, img image.Image) error {
+			grid, err := p.Analyze(ctx, img)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "Frame %d:\n", index); err != nil {
+				return err
+			}
+			if err := renderer.Render(ctx, w, grid); err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, "\n")
+			return err
+		})
+	case MP4, GIF:
+		renderer := p.ImageRenderer(o.mono)
+		var encoder interface {
+			WriteFrame(img image.Image) error
+			Close() error
+			Abort()
+		}
+		if format == GIF {
+			encoder = converter.NewGIFEncoder(w, o.fps)
+		} else {
+			encoder = converter.NewVideoEncoder(ctx, w, o.fps, "mp4")
+		}
+		err := converter.DecodeVideo(ctx, r, o.fps, func(index int, img image.Image) error {
+			grid, err := p.Analyze(ctx, img)
+			if err != nil {
+				return err
+			}
+			frame, err := renderer.Draw(ctx, grid)
+			if err != nil {
+				return err
+			}
+			return encoder.WriteFrame(frame)
+		})
+		if err != nil {
+			// Kill ffmpeg without finalizing the MP4 and drop buffered GIF frames
+			encoder.Abort()
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("unsupported video format: %s", format)
+	}
+}
+
+// RegisterFormat adds an output format usable by Render and ConvertImage.
+// extensions, such as ".xyz", let FormatForPath detect the format from a file
+// name. It is meant to be called from init and panics if the name or an
+// extension is already registered.
+func RegisterFormat(name Format, extensions []string, newRenderer func(opts RenderOptions) Renderer) {
+	converter.RegisterFormat(converter.Format{
+		Name:       string(name),
+		Extensions: extensions,
+		New:        newRenderer,
+	})
+}
+
+// FormatForPath returns the format registered for the extension of path
+func FormatForPath(path string) (Format, bool) {
+	f, ok := converter.FormatForPath(path)
+	return Format(f.Name), ok
+}
+
+// Formats returns the names of all registered formats in sorted order
+func Formats() []Format {
+	var names []Format
+	for _, f := range converter.Formats() {
+		names = append(names, Format(f.Name))
+	}
+	return names
+}
+
+// renderer returns the renderer for a registered format
+func (o *options) renderer(p *converter.Pipeline, format Format) (Renderer, error) {
+	f, ok := converter.LookupFormat(string(format))
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+	return converter.NewRenderer(f, p.RenderOptions(o.mono))
+}
+
+// context attaches the progress reporter to ctx
+func (o *options) context(ctx context.Context) context.Context {
+	if o.reporter == nil {
+		return ctx
+	}
+	return progress.WithReporter(ctx, o.reporter)
+}
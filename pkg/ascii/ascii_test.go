@@ -0,0 +1,322 @@
+package ascii
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testFont 仓库中的字体文件，测试在包目录下运行
+const testFont = "../../fonts/DejaVuSansMono-Bold.ttf"
+
+// splitImage 左半为黑色、右半为白色的图像
+func splitImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= width/2 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+// 测试 Convert 按选项生成网格
+func TestConvert(t *testing.T) {
+	grid, err := Convert(context.Background(), splitImage(80, 40), WithColumns(8), WithCharMode("simple"))
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if grid.Cols != 8 || grid.Rows != 2 {
+		t.Fatalf("expected 8x2 grid, got %dx%d", grid.Cols, grid.Rows)
+	}
+	// 深色背景下默认不反转，暗的一侧使用最浓的字符
+	left, right := grid.At(0, 0), grid.At(0, 7)
+	if left.Rune != '@' || right.Rune != '.' {
+		t.Errorf("expected '@' and '.', got %q and %q", left.Rune, right.Rune)
+	}
+	if right.Brightness < 0.999 || right.FG != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("unexpected right cell %+v", right)
+	}
+
+	if _, err := Convert(context.Background(), splitImage(8, 8), WithGlyphMode("fancy")); err == nil {
+		t.Error("expected error for unknown glyph mode")
+	}
+}
+
+// 测试 Render 输出文本和 JPEG
+func TestRender(t *testing.T) {
+	grid, err := Convert(context.Background(), splitImage(80, 40), WithColumns(8), WithCharMode("simple"))
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := Render(&text, grid, Text); err != nil {
+		t.Fatalf("Render text failed: %v", err)
+	}
+	if text.String() != "@@@@....\n@@@@....\n" {
+		t.Errorf("unexpected text %q", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := Render(&out, grid, JPEG, WithFont(testFont, 1)); err != nil {
+		t.Fatalf("Render JPEG failed: %v", err)
+	}
+	img, err := jpeg.Decode(&out)
+	if err != nil {
+		t.Fatalf("invalid JPEG: %v", err)
+	}
+	if img.Bounds().Dx() != 80 || img.Bounds().Dy() != 40 {
+		t.Errorf("expected 80x40 image, got %v", img.Bounds())
+	}
+
+	out.Reset()
+	if err := Render(&out, grid, PNG, WithFont(testFont, 1), WithTransparent(true)); err != nil {
+		t.Fatalf("Render PNG failed: %v", err)
+	}
+	if _, format, err := image.Decode(&out); err != nil || format != "png" {
+		t.Errorf("invalid PNG: %v, %s", err, format)
+	}
+	if err := Render(&out, grid, JPEG, WithTransparent(true)); err == nil {
+		t.Error("expected error for transparent JPEG")
+	}
+
+	var ansi bytes.Buffer
+	if err := Render(&ansi, grid, ANSI, WithANSIColors("16")); err != nil {
+		t.Fatalf("Render ANSI failed: %v", err)
+	}
+	if !strings.Contains(ansi.String(), "\x1b[30m@@@@\x1b[97m....") {
+		t.Errorf("unexpected ANSI text %q", ansi.String())
+	}
+
+	var page bytes.Buffer
+	if err := Render(&page, grid, HTML, WithHTML(true, "monospace", false)); err != nil {
+		t.Fatalf("Render HTML failed: %v", err)
+	}
+	if !strings.HasPrefix(page.String(), "<pre") || !strings.Contains(page.String(), "@@@@</span>") {
+		t.Errorf("unexpected HTML %q", page.String())
+	}
+
+	var svg bytes.Buffer
+	if err := Render(&svg, grid, SVG, WithFont(testFont, 1), WithSVGFont("outline")); err != nil {
+		t.Fatalf("Render SVG failed: %v", err)
+	}
+	if !strings.Contains(svg.String(), `viewBox="0 0 80 40"`) || !strings.Contains(svg.String(), `<path id="g40"`) {
+		t.Errorf("unexpected SVG %q", svg.String())
+	}
+
+	var ans bytes.Buffer
+	if err := Render(&ans, grid, ANS, WithSAUCE("Split", "tester", "", "")); err != nil {
+		t.Fatalf("Render ANS failed: %v", err)
+	}
+	if !strings.Contains(ans.String(), "\x1b[30m@@@@\x1b[1;37m....") || !strings.Contains(ans.String(), "\x1aSAUCE00Split") {
+		t.Errorf("unexpected ANS %q", ans.String())
+	}
+
+	for _, format := range []Format{JSON, GridBinary} {
+		var export bytes.Buffer
+		if err := Render(&export, grid, format); err != nil {
+			t.Fatalf("Render %s failed: %v", format, err)
+		}
+		read, err := ReadGrid(&export)
+		if err != nil {
+			t.Fatalf("ReadGrid %s failed: %v", format, err)
+		}
+		text.Reset()
+		if err := Render(&text, read, Text); err != nil || text.String() != "@@@@....\n@@@@....\n" {
+			t.Errorf("%s: unexpected text %q, %v", format, text.String(), err)
+		}
+	}
+
+	if err := Render(&out, grid, Format("xyz")); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+// 测试 ReadText 解析 ANSI 文本并按单元格尺寸和留白渲染图像
+func TestReadText(t *testing.T) {
+	grid, err := ReadText(strings.NewReader("ab\n\x1b[31mcde\x1b[0m\n"), WithFont(testFont, 1), WithCellSize(0, 20))
+	if err != nil {
+		t.Fatalf("ReadText failed: %v", err)
+	}
+	if grid.Rows != 2 || grid.Cols != 3 || grid.CellHeight != 20 || grid.CellWidth <= 0 {
+		t.Fatalf("unexpected grid %dx%d with %vx%v cells", grid.Cols, grid.Rows, grid.CellWidth, grid.CellHeight)
+	}
+	if grid.At(1, 0).FG == grid.At(0, 0).FG {
+		t.Errorf("expected colored second row, got %+v", grid.At(1, 0))
+	}
+
+	var out bytes.Buffer
+	if err := Render(&out, grid, PNG, WithFont(testFont, 1), WithPadding(4)); err != nil {
+		t.Fatalf("Render PNG failed: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != grid.Width+8 || img.Bounds().Dy() != 2*20+8 {
+		t.Errorf("unexpected image size %v", img.Bounds())
+	}
+}
+
+// 测试 ConvertImage 从流中读取图像并报告进度
+func TestConvertImage(t *testing.T) {
+	var in bytes.Buffer
+	if err := png.Encode(&in, splitImage(80, 40)); err != nil {
+		t.Fatalf("failed to encode input: %v", err)
+	}
+
+	var stages []string
+	reporter := ReporterFunc(func(e Event) {
+		if len(stages) == 0 || stages[len(stages)-1] != e.Stage {
+			stages = append(stages, e.Stage)
+		}
+	})
+	var out bytes.Buffer
+	err := ConvertImage(context.Background(), &in, &out, Text, WithColumns(8), WithCharMode("simple"), WithProgress(reporter))
+	if err != nil {
+		t.Fatalf("ConvertImage failed: %v", err)
+	}
+	if out.String() != "@@@@....\n@@@@....\n" {
+		t.Errorf("unexpected text %q", out.String())
+	}
+	if len(stages) == 0 || stages[0] != "select" {
+		t.Errorf("expected progress events starting with select, got %v", stages)
+	}
+}
+
+// fakeFFmpeg 在 PATH 中放入模拟的 ffmpeg：
+// 解码时丢弃输入并输出一白一黑两帧 8x8 的 PPM 图像，编码时原样输出原始帧数据
+func fakeFFmpeg(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+*rawvideo*) exec cat ;;
+esac
+cat >/dev/null
+printf 'P6\n8 8\n255\n'
+head -c 192 /dev/zero | tr '\0' '\377'
+printf 'P6\n8 8\n255\n'
+head -c 192 /dev/zero
+`
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// 测试 ConvertVideo 逐帧输出文本
+func TestConvertVideoText(t *testing.T) {
+	fakeFFmpeg(t)
+	var out bytes.Buffer
+	err := ConvertVideo(context.Background(), strings.NewReader("video"), &out, Text, WithColumns(2), WithCharMode("simple"))
+	if err != nil {
+		t.Fatalf("ConvertVideo failed: %v", err)
+	}
+	expected := "Frame 0:\n..\n\nFrame 1:\n@@\n\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+// 测试 ConvertVideo 将绘制的帧交给编码器
+func TestConvertVideoMP4(t *testing.T) {
+	fakeFFmpeg(t)
+	var out bytes.Buffer
+	err := ConvertVideo(context.Background(), strings.NewReader("video"), &out, MP4, WithColumns(2), WithFont(testFont, 1))
+	if err != nil {
+		t.Fatalf("ConvertVideo failed: %v", err)
+	}
+	// 模拟的编码器原样输出两帧 8x8 的 RGBA 数据
+	if out.Len() != 2*8*8*4 {
+		t.Errorf("expected %d bytes of raw frames, got %d", 2*8*8*4, out.Len())
+	}
+
+	if err := ConvertVideo(context.Background(), strings.NewReader("video"), &out, JPEG); err == nil {
+		t.Error("expected error for unsupported video format")
+	}
+}
+
+// 测试 GIF 输入和输出不依赖 ffmpeg
+func TestConvertVideoGIF(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	palette := color.Palette{color.Black, color.White}
+	var in bytes.Buffer
+	anim := &gif.GIF{Delay: []int{10, 10}}
+	for _, index := range []uint8{0, 1} {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+		for i := range frame.Pix {
+			frame.Pix[i] = index
+		}
+		anim.Image = append(anim.Image, frame)
+	}
+	if err := gif.EncodeAll(&in, anim); err != nil {
+		t.Fatalf("failed to encode input: %v", err)
+	}
+
+	var out bytes.Buffer
+	err := ConvertVideo(context.Background(), bytes.NewReader(in.Bytes()), &out, GIF, WithColumns(2), WithFont(testFont, 1))
+	if err != nil {
+		t.Fatalf("ConvertVideo failed: %v", err)
+	}
+	result, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+	// 两帧各 0.1 秒，按默认的每秒 10 帧采样
+	if len(result.Image) != 2 || result.Delay[0] != 10 {
+		t.Errorf("expected 2 frames of 10, got %d with %v", len(result.Image), result.Delay)
+	}
+}
+
+// sizeRenderer 输出网格尺寸的测试格式
+type sizeRenderer struct{}
+
+func (sizeRenderer) Render(ctx context.Context, w io.Writer, grid *Grid) error {
+	_, err := fmt.Fprintf(w, "%dx%d", grid.Cols, grid.Rows)
+	return err
+}
+
+// 测试通过公开接口注册的格式
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("test-size", []string{".size"}, func(opts RenderOptions) Renderer {
+		return sizeRenderer{}
+	})
+	if format, ok := FormatForPath("grid.SIZE"); !ok || format != "test-size" {
+		t.Fatalf("expected test-size for .SIZE, got %q", format)
+	}
+
+	var in bytes.Buffer
+	if err := png.Encode(&in, splitImage(80, 40)); err != nil {
+		t.Fatalf("failed to encode input: %v", err)
+	}
+	var out bytes.Buffer
+	if err := ConvertImage(context.Background(), &in, &out, "test-size", WithColumns(8)); err != nil {
+		t.Fatalf("ConvertImage failed: %v", err)
+	}
+	if out.String() != "8x2" {
+		t.Errorf("expected 8x2, got %q", out.String())
+	}
+
+	found := false
+	for _, format := range Formats() {
+		found = found || format == "test-size"
+	}
+	if !found {
+		t.Errorf("expected test-size in %v", Formats())
+	}
+}
@@ -0,0 +1,23 @@
+// Package ascii converts images and videos to ASCII art.
+//
+// Conversion happens in two steps. Convert analyzes an image into a Grid of
+// cells, each holding a rune, its foreground and background colors and the
+// sampled brightness. Render writes a Grid in one of the supported formats.
+// ConvertImage and ConvertVideo combine both steps for streams, so callers do
+// not need temporary files or paths. Video conversion runs ffmpeg, which must
+// be on PATH.
+//
+// Behavior is configured with functional options such as WithColumns and
+// WithGlyphMode; the defaults match the ascii command line tool.
+//
+// # Compatibility
+//
+// This package follows semantic versioning. Within a major version, exported
+// identifiers are not removed and their signatures do not change; new options,
+// formats and Grid or Cell fields may be added. Output for a given input and set
+// of options may change between minor versions as the algorithms improve.
+// Version reports the version of the API.
+package ascii
+
+// Version is the semantic version of the public API
+const Version = "1.0.0"
@@ -0,0 +1,103 @@
+package ascii_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+
+	"github.com/hai119/Go-ASCII-generator/pkg/ascii"
+)
+
+// gradient returns a horizontal black-to-white gradient
+func gradient(width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 255 / (width - 1))})
+		}
+	}
+	return img
+}
+
+func ExampleConvert() {
+	// Dark pixels map to dense characters, as on a white page
+	tone := ascii.DefaultTone()
+	tone.Invert = "false"
+
+	grid, err := ascii.Convert(context.Background(), gradient(100, 20),
+		ascii.WithColumns(10),
+		ascii.WithCharMode("simple"),
+		ascii.WithTone(tone))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, cell := range grid.Row(0) {
+		fmt.Printf("%c", cell.Rune)
+	}
+	fmt.Println()
+	// Output: @%#*++=-:.
+}
+
+func ExampleRender() {
+	grid, err := ascii.Convert(context.Background(), gradient(100, 20),
+		ascii.WithColumns(10),
+		ascii.WithGlyphMode("halfblock"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Block glyphs carry background colors, so text output uses ANSI truecolor escapes
+	if err := ascii.Render(os.Stdout, grid, ascii.Text); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleConvertImage() {
+	var in bytes.Buffer
+	if err := png.Encode(&in, gradient(100, 20)); err != nil {
+		log.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := ascii.ConvertImage(context.Background(), &in, &out, ascii.JPEG,
+		ascii.WithColumns(20),
+		ascii.WithFont("../../fonts/DejaVuSansMono-Bold.ttf", 1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	img, _, err := image.Decode(&out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(img.Bounds().Size())
+	// Output: (100,20)
+}
+
+func ExampleConvertVideo() {
+	in, err := os.Open("input.mp4")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	out, err := os.Create("output.mp4")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	// Frames are streamed through ffmpeg without temporary files
+	err = ascii.ConvertVideo(context.Background(), in, out, ascii.MP4,
+		ascii.WithColumns(120),
+		ascii.WithFPS(15),
+		ascii.WithProgress(ascii.ReporterFunc(func(e ascii.Event) {
+			log.Printf("%s %d/%d", e.Stage, e.Done, e.Total)
+		})))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,244 @@
+package ascii
+
+import (
+	"os"
+
+	"github.com/hai119/Go-ASCII-generator/internal/config"
+	"github.com/hai119/Go-ASCII-generator/internal/converter"
+	"github.com/hai119/Go-ASCII-generator/internal/progress"
+)
+
+// Tone holds the tone-mapping stage applied between sampling and glyph selection
+type Tone = config.ToneConfig
+
+// DefaultTone returns the tone mapping that leaves brightness unchanged
+// except for the automatic inversion on light backgrounds.
+func DefaultTone() Tone {
+	return Tone{
+		Gamma:      1,
+		Contrast:   1,
+		WhitePoint: 1,
+		Equalize:   "none",
+		ClaheClip:  2,
+		Invert:     "auto",
+	}
+}
+
+// Reporter receives progress events during a conversion
+type Reporter = progress.Reporter
+
+// ReporterFunc adapts a function to a Reporter
+type ReporterFunc = progress.ReporterFunc
+
+// Event is a progress event; see the progress stage names in the command line documentation
+type Event = progress.Event
+
+// Option configures a conversion
+type Option func(*options)
+
+// options collects the settings of a conversion
+type options struct {
+	cfg      config.Config
+	fps      int
+	mono     bool
+	reporter Reporter
+}
+
+// newOptions returns the defaults of the ascii command line tool with opts applied
+func newOptions(opts []Option) *options {
+	o := &options{
+		cfg: config.Config{
+			NumCols:       100,
+			Background:    "black",
+			Scale:         1,
+			Language:      "english",
+			GlyphMode:     "brightness",
+			EdgeThreshold: 0.25,
+			EdgeGlyphs:    "ascii",
+			Dither:        "none",
+			ColorSpace:    "srgb",
+			Tone:          DefaultTone(),
+		},
+		fps: 10,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithColumns sets the number of characters per row
+func WithColumns(n int) Option {
+	return func(o *options) { o.cfg.NumCols = n }
+}
+
+// WithLanguage selects the character set language: general, english, chinese, japanese or korean
+func WithLanguage(language string) Option {
+	return func(o *options) { o.cfg.Language = language }
+}
+
+// WithCharMode selects the character ramp within the language, e.g. simple,
+// complex, standard or shade (the CP437 shading blocks, suited to ANS output)
+func WithCharMode(mode string) Option {
+	return func(o *options) { o.cfg.CharMode = mode }
+}
+
+// WithGlyphMode selects how glyphs are chosen: brightness, structure, edge,
+// braille, halfblock, quadrant or sextant
+func WithGlyphMode(mode string) Option {
+	return func(o *options) { o.cfg.GlyphMode = mode }
+}
+
+// WithEdges configures the edge glyph mode: the normalized Sobel magnitude
+// above which a pixel is an edge, and the orientation glyphs (ascii or box)
+func WithEdges(threshold float64, glyphs string) Option {
+	return func(o *options) {
+		o.cfg.EdgeThreshold = threshold
+		o.cfg.EdgeGlyphs = glyphs
+	}
+}
+
+// WithCalibration sorts the character ramp by measured glyph density,
+// optionally resampled to levels evenly spaced densities (0 keeps all characters)
+func WithCalibration(levels int) Option {
+	return func(o *options) {
+		o.cfg.Calibrate = true
+		o.cfg.RampLevels = levels
+	}
+}
+
+// WithDither selects dithering before glyph lookup: none, floyd-steinberg, atkinson, jjn or bayer
+func WithDither(mode string) Option {
+	return func(o *options) { o.cfg.Dither = mode }
+}
+
+// WithColorSpace selects the color space for brightness and color averaging: srgb, linear or oklab
+func WithColorSpace(space string) Option {
+	return func(o *options) { o.cfg.ColorSpace = space }
+}
+
+// WithTone sets the tone mapping; start from DefaultTone and change the fields you need
+func WithTone(tone Tone) Option {
+	return func(o *options) { o.cfg.Tone = tone }
+}
+
+// WithBackground sets the background of rendered images: black or white
+func WithBackground(background string) Option {
+	return func(o *options) { o.cfg.Background = background }
+}
+
+// WithMono draws rendered images in a single color contrasting with the background
+func WithMono(mono bool) Option {
+	return func(o *options) { o.mono = mono }
+}
+
+// WithFont sets the TrueType font file and scale used for rendered images.
+// By default the font depends on the language and is looked up in a fonts
+// directory relative to the working directory.
+func WithFont(path string, scale float64) Option {
+	return func(o *options) {
+		o.cfg.FontPath = path
+		o.cfg.Scale = scale
+	}
+}
+
+// WithJPEGQuality sets the JPEG quality from 1 to 100; 0 uses the default of 75
+func WithJPEGQuality(quality int) Option {
+	return func(o *options) { o.cfg.JPEGQuality = quality }
+}
+
+// WithPNGCompression sets the PNG and TIFF compression: default, none, fast or best
+func WithPNGCompression(level string) Option {
+	return func(o *options) { o.cfg.PNGCompression = level }
+}
+
+// WithTransparent leaves the background of rendered images transparent.
+// Only formats with an alpha channel, PNG, TIFF and WebP, support it.
+func WithTransparent(transparent bool) Option {
+	return func(o *options) { o.cfg.Transparent = transparent }
+}
+
+// WithANSIColors sets the colors of ANSI output: truecolor, 256, 16 or none.
+// The default, auto, uses truecolor.
+func WithANSIColors(mode string) Option {
+	return func(o *options) { o.cfg.ANSIColors = mode }
+}
+
+// WithANSIBackground paints the WithBackground color behind every character of ANSI output
+func WithANSIBackground(background bool) Option {
+	return func(o *options) { o.cfg.ANSIBackground = background }
+}
+
+// DetectANSIColors returns the ANSI color mode supported by the terminal
+// according to the NO_COLOR, COLORTERM and TERM environment variables
+func DetectANSIColors() string {
+	switch converter.DetectColorDepth(os.Getenv) {
+	case converter.ColorTrue:
+		return "truecolor"
+	case converter.Color256:
+		return "256"
+	case converter.Color16:
+		return "16"
+	default:
+		return "none"
+	}
+}
+
+// WithHTML configures HTML output: fragment writes only the <pre> element
+// instead of a full page, font is a CSS font-family stack ("" uses common
+// monospace fonts) and theme takes the colors and font from the CSS variables
+// --ascii-bg, --ascii-fg and --ascii-font when the embedding page sets them.
+func WithHTML(fragment bool, font string, theme bool) Option {
+	return func(o *options) {
+		o.cfg.HTMLFragment = fragment
+		o.cfg.HTMLFont = font
+		o.cfg.HTMLTheme = theme
+	}
+}
+
+// WithSVGFont sets how SVG output handles the font: reference names the font
+// family, embed inlines the font file and outline converts glyphs to paths so
+// the output looks the same without the font installed
+func WithSVGFont(mode string) Option {
+	return func(o *options) { o.cfg.SVGFont = mode }
+}
+
+// WithCellSize sets the cell size in pixels of grids read by ReadText; 0 keeps
+// the size measured from the font
+func WithCellSize(width, height int) Option {
+	return func(o *options) {
+		o.cfg.CellWidth = width
+		o.cfg.CellHeight = height
+	}
+}
+
+// WithPadding adds n pixels of background around rendered raster images
+func WithPadding(n int) Option {
+	return func(o *options) { o.cfg.Padding = n }
+}
+
+// WithSAUCE sets the SAUCE record appended to ANS and XBin output. font names
+// the bitmap font viewers should use for ANS output; "" uses "IBM VGA".
+func WithSAUCE(title, author, group, font string) Option {
+	return func(o *options) {
+		o.cfg.SAUCETitle = title
+		o.cfg.SAUCEAuthor = author
+		o.cfg.SAUCEGroup = group
+		o.cfg.SAUCEFont = font
+	}
+}
+
+// WithWorkers sets the number of parallel workers; 0 uses GOMAXPROCS
+func WithWorkers(n int) Option {
+	return func(o *options) { o.cfg.Workers = n }
+}
+
+// WithFPS sets the number of frames per second sampled from videos
+func WithFPS(fps int) Option {
+	return func(o *options) { o.fps = fps }
+}
+
+// WithProgress reports progress events to r
+func WithProgress(r Reporter) Option {
+	return func(o *options) { o.reporter = r }
+}